@@ -0,0 +1,342 @@
+package generator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Drift contains the asset paths that differ between an existing generated file
+// and what would be generated from its directory now.
+type Drift struct {
+	Added   []string
+	Removed []string
+	Changed []string
+	Files   []string // generated files whose source differs other than in their assets i.e. generated with other options
+}
+
+// HasDrift returns true if any asset was added, removed or changed or any
+// generated file differs
+func (d *Drift) HasDrift() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0 || len(d.Files) > 0
+}
+
+// Check generates the static files described by opts in memory and compares
+// them with the existing opts.OutputFile, and its dev file when opts.BuildTags
+// is set, which are never modified.
+//
+// Assets are compared by path and decompressed contents only, file metadata such
+// as modification times differs between checkouts of the same files and is ignored.
+// The rest of the source, generated from the options, must be identical.
+func Check(ctx context.Context, opts *Options) (*Drift, error) {
+
+	g, err := newGenerator(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = g.prepare(); err != nil {
+		return nil, err
+	}
+
+	var buff bytes.Buffer
+
+	if err = g.write(&buff); err != nil {
+		return nil, err
+	}
+
+	generated := [][2]string{{opts.OutputFile, buff.String()}}
+
+	if opts.BuildTags {
+
+		src, err := g.devSource()
+		if err != nil {
+			return nil, err
+		}
+
+		generated = append(generated, [2]string{devFileName(opts.OutputFile), string(src)})
+	}
+
+	expected, err := parseAssets(opts.OutputFile, buff.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]string{}
+
+	b, err := ioutil.ReadFile(opts.OutputFile)
+	if err == nil {
+
+		if existing, err = parseAssets(opts.OutputFile, b); err != nil {
+			return nil, err
+		}
+
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	drift := new(Drift)
+
+	for path, digest := range expected {
+
+		existingDigest, found := existing[path]
+
+		switch {
+		case !found:
+			drift.Added = append(drift.Added, path)
+		case existingDigest != digest:
+			drift.Changed = append(drift.Changed, path)
+		}
+	}
+
+	for path := range existing {
+
+		if _, found := expected[path]; !found {
+			drift.Removed = append(drift.Removed, path)
+		}
+	}
+
+	for _, file := range generated {
+
+		b, err := ioutil.ReadFile(file[0])
+		if os.IsNotExist(err) {
+			drift.Files = append(drift.Files, file[0])
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		existingSrc, err := skeleton(file[0], b)
+		if err != nil {
+			return nil, err
+		}
+
+		expectedSrc, err := skeleton(file[0], []byte(file[1]))
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(existingSrc, expectedSrc) {
+			drift.Files = append(drift.Files, file[0])
+		}
+	}
+
+	sort.Strings(drift.Added)
+	sort.Strings(drift.Removed)
+	sort.Strings(drift.Changed)
+
+	return drift, nil
+}
+
+// skeleton returns the generated go source src without everything derived from
+// the assets, which parseAssets compares, or the digest header; that is the
+// static.DirFile literals, go:embed directives and the path constants and list.
+// What's left is generated from the options alone.
+func skeleton(filename string, src []byte) ([]byte, error) {
+
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var omit [][2]token.Pos
+
+	for _, group := range f.Comments {
+
+		for _, c := range group.List {
+
+			if strings.HasPrefix(c.Text, "//go:embed ") || strings.HasPrefix(c.Text, "// "+digestPrefix) {
+				omit = append(omit, [2]token.Pos{c.Slash, c.End()})
+			}
+		}
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+
+		switch n := n.(type) {
+		case *ast.CompositeLit:
+
+			// static.DirFile, including the ModTime of every file, and the []string path list
+			if sel, ok := n.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "DirFile" {
+				omit = append(omit, [2]token.Pos{n.Lbrace + 1, n.Rbrace})
+				return false
+			}
+
+			if array, ok := n.Type.(*ast.ArrayType); ok {
+
+				if ident, ok := array.Elt.(*ast.Ident); ok && ident.Name == "string" {
+					omit = append(omit, [2]token.Pos{n.Lbrace + 1, n.Rbrace})
+					return false
+				}
+			}
+
+		case *ast.GenDecl:
+
+			if n.Tok == token.CONST && n.Lparen.IsValid() {
+				omit = append(omit, [2]token.Pos{n.Lparen + 1, n.Rparen})
+				return false
+			}
+		}
+
+		return true
+	})
+
+	sort.Slice(omit, func(i, j int) bool { return omit[i][0] < omit[j][0] })
+
+	var buff bytes.Buffer
+
+	offset := 0
+
+	for _, o := range omit {
+
+		start, end := fset.Position(o[0]).Offset, fset.Position(o[1]).Offset
+
+		if start < offset {
+			continue
+		}
+
+		buff.Write(src[offset:start])
+		offset = end
+	}
+
+	buff.Write(src[offset:])
+
+	return buff.Bytes(), nil
+}
+
+// parseAssets parses the generated go source src and returns a map of every
+// embedded asset path to a digest of its decompressed contents, empty for
+// directories and files embedded using go:embed, as their contents are only
+// read at compile time.
+func parseAssets(filename string, src []byte) (map[string]string, error) {
+
+	f, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := map[string]string{}
+
+	for _, group := range f.Comments {
+
+		for _, c := range group.List {
+
+			if !strings.HasPrefix(c.Text, "//go:embed ") {
+				continue
+			}
+
+			pattern := strings.TrimPrefix(c.Text, "//go:embed ")
+
+			if unquoted, err := strconv.Unquote(pattern); err == nil {
+				pattern = unquoted
+			}
+
+			assets[pattern] = ""
+		}
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+
+		if err != nil {
+			return false
+		}
+
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+
+		var path, compressed string
+		var isDir, hasPath bool
+
+		for _, elt := range lit.Elts {
+
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+
+			switch key.Name {
+			case "Path":
+				if path, err = unquote(kv.Value); err != nil {
+					return false
+				}
+				hasPath = true
+			case "Compressed":
+				if compressed, err = unquote(kv.Value); err != nil {
+					return false
+				}
+			case "IsDir":
+				if ident, ok := kv.Value.(*ast.Ident); ok {
+					isDir = ident.Name == "true"
+				}
+			}
+		}
+
+		if !hasPath {
+			return true
+		}
+
+		if isDir {
+			assets[path] = ""
+			return true
+		}
+
+		assets[path], err = digest(compressed)
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return assets, nil
+}
+
+func unquote(expr ast.Expr) (string, error) {
+
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", nil
+	}
+
+	return strconv.Unquote(lit.Value)
+}
+
+// digest returns a digest of the decompressed contents of the base64 encoded gzip data
+func digest(compressed string) (string, error) {
+
+	reader, err := gzip.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(compressed)))
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+
+	if _, err = io.Copy(h, reader); err != nil {
+		return "", err
+	}
+
+	return string(h.Sum(nil)), nil
+}
@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// initialisms are the words written in upper case within generated constant
+// names, following the Go convention for initialisms and common file extensions
+var initialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "CSV": true,
+	"DNS": true, "EOF": true, "GIF": true, "GUID": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ICO": true, "ID": true, "IP": true, "JPEG": true, "JPG": true,
+	"JS": true, "JSON": true, "PDF": true, "PNG": true, "RPC": true, "SQL": true,
+	"SSH": true, "SVG": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true,
+	"UI": true, "UID": true, "UUID": true, "URI": true, "URL": true, "UTF8": true,
+	"WASM": true, "XML": true, "XSRF": true, "XSS": true, "YAML": true,
+}
+
+// writeConstants writes a constant for the path of every embedded file and a
+// sorted list of all of them, so references are checked by the compiler
+func (g *generator) writeConstants(funcName string) {
+
+	var paths []string
+
+	g.collectPaths(g.root, &paths)
+
+	names := make([]string, len(paths))
+	width := 0
+
+	// names already declared by the generated source
+	seen := map[string]bool{
+		funcName + "Paths": true,
+	}
+
+	if len(g.opts.Accessor) > 0 {
+		seen[g.opts.Accessor] = true
+		seen[g.opts.Accessor+"Config"] = true
+	}
+
+	for i, p := range paths {
+
+		ident := identifier(p)
+
+		// i.e. a path of only punctuation, which could otherwise be the blank identifier
+		if len(ident) == 0 {
+			ident = "File"
+		}
+
+		name := funcName + ident
+
+		// i.e. app-css and app.css, the later sorted path gets a numbered suffix
+		for n := 2; seen[name]; n++ {
+			name = funcName + ident + strconv.Itoa(n)
+		}
+
+		seen[name] = true
+		names[i] = name
+
+		if l := utf8.RuneCountInString(name); l > width {
+			width = l
+		}
+	}
+
+	if len(paths) > 0 {
+
+		fmt.Fprintf(g.writer, "\n// %s file paths, for use with the *static.Files returned by newStatic%s\nconst (\n", funcName, funcName)
+
+		for i, p := range paths {
+			fmt.Fprintf(g.writer, "\t%s%s = %q\n", names[i], strings.Repeat(" ", width-utf8.RuneCountInString(names[i])), p)
+		}
+
+		g.writer.WriteString(")\n")
+	}
+
+	fmt.Fprintf(g.writer, "\n// %sPaths contains the path of every %s file, sorted\nvar %sPaths = []string{", funcName, funcName, funcName)
+
+	if len(names) > 0 {
+		g.writer.WriteString("\n")
+	}
+
+	for _, name := range names {
+		fmt.Fprintf(g.writer, "\t%s,\n", name)
+	}
+
+	g.writer.WriteString("}\n")
+}
+
+func (g *generator) collectPaths(e *entry, paths *[]string) {
+
+	if !e.info.IsDir() {
+		*paths = append(*paths, filepath.ToSlash(e.path))
+		return
+	}
+
+	for _, nested := range e.files {
+		g.collectPaths(nested, paths)
+	}
+}
+
+// identifier returns the exported identifier for path i.e. /css/app.css => CSSAppCSS
+func identifier(path string) string {
+
+	words := strings.FieldsFunc(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var ident strings.Builder
+
+	for _, word := range words {
+
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			ident.WriteString(upper)
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(word)
+		ident.WriteRune(unicode.ToUpper(r))
+		ident.WriteString(word[size:])
+	}
+
+	return ident.String()
+}
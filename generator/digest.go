@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// digestPrefix precedes the digest recorded in the generated file header
+	digestPrefix = "statics:digest sha256:"
+
+	// digestVersion is bumped whenever the generated output changes for the same
+	// inputs, so files generated by older versions are regenerated
+	digestVersion = 3
+)
+
+// computeDigest returns a digest of the options affecting the output and of the
+// path, FileInfo and contents of every file and directory to be embedded.
+func (g *generator) computeDigest() (string, error) {
+
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%d %q %q %q %q %q %q %t %t %t %q %q %q\n", digestVersion, g.opts.StaticDir, g.opts.OutputFile, g.opts.Pkg, g.opts.Group, g.opts.Ignore, g.opts.Prefix, g.opts.BuildTags, g.opts.Embed, g.opts.Constants, g.opts.Accessor, g.module, g.modDir)
+
+	if err := g.digestEntry(h, g.root); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (g *generator) digestEntry(h hash.Hash, e *entry) error {
+
+	if err := g.ctx.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(h, "%q %q %d %d %d %t\n", e.path, e.name, e.info.Size(), e.info.Mode(), e.info.ModTime().Unix(), e.info.IsDir())
+
+	if !e.info.IsDir() {
+
+		f, err := os.Open(e.src)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(h, f)
+		f.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, nested := range e.files {
+
+		if err := g.digestEntry(h, nested); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readDigest returns the digest recorded in the header of the generated file
+// name, or an empty string if there is none
+func readDigest(name string) string {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+
+		if strings.HasPrefix(line, "// "+digestPrefix) {
+			return strings.TrimPrefix(line, "// "+digestPrefix)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,206 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// prepareEmbed validates the files can be embedded using go:embed and collects
+// their patterns. go:embed patterns are relative to the output files directory,
+// can't reach outside of it and never follow symlinks.
+func (g *generator) prepareEmbed() error {
+
+	outDir, err := filepath.Abs(filepath.Dir(g.opts.OutputFile))
+	if err != nil {
+		return err
+	}
+
+	base, err := g.base()
+	if err != nil {
+		return err
+	}
+
+	staticDir, err := filepath.Abs(g.opts.StaticDir)
+	if err != nil {
+		return err
+	}
+
+	root, err := filepath.Rel(outDir, base)
+	if err != nil || !within(root) {
+		return fmt.Errorf("the static files directory '%s' must be within the output files directory '%s' when using go:embed", base, outDir)
+	}
+
+	if rel, err := filepath.Rel(base, staticDir); err != nil || !within(rel) {
+		return fmt.Errorf("the static files directory '%s' must be within the prefix '%s' when using go:embed", staticDir, base)
+	}
+
+	realOutDir, err := filepath.EvalSymlinks(outDir)
+	if err != nil {
+		return err
+	}
+
+	g.embedRoot = filepath.ToSlash(root)
+	g.embeds = g.embeds[:0]
+
+	return g.collectEmbeds(g.root, realOutDir)
+}
+
+func (g *generator) collectEmbeds(e *entry, realOutDir string) error {
+
+	if e.info.IsDir() {
+
+		for _, nested := range e.files {
+
+			if err := g.collectEmbeds(nested, realOutDir); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	name := path.Join(g.embedRoot, filepath.ToSlash(e.path))
+
+	if strings.ContainsAny(name, "*?[\\") {
+		return fmt.Errorf("go:embed cannot embed '%s' as its name contains glob characters", name)
+	}
+
+	for _, elem := range strings.Split(name, "/") {
+
+		if reason := badEmbedName(elem); len(reason) > 0 {
+			return fmt.Errorf("go:embed cannot embed '%s' as '%s' %s", name, elem, reason)
+		}
+	}
+
+	real, err := filepath.EvalSymlinks(filepath.Join(realOutDir, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+
+	if real != filepath.Join(realOutDir, filepath.FromSlash(name)) {
+		return fmt.Errorf("go:embed cannot embed '%s' as it is, or is within, a symlink", name)
+	}
+
+	// as the go command, a go.mod below the output files directory starts another module
+	for dir := filepath.Dir(real); dir != realOutDir; dir = filepath.Dir(dir) {
+
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+
+			rel, _ := filepath.Rel(realOutDir, dir)
+
+			return fmt.Errorf("go:embed cannot embed '%s' as '%s' contains a go.mod, making it a different module", name, filepath.ToSlash(rel))
+		}
+	}
+
+	g.embeds = append(g.embeds, embedPattern(name))
+
+	return nil
+}
+
+// windowsNames are the file names, ignoring any extension, reserved on Windows
+var windowsNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// badEmbedName returns why the go command refuses to embed a file or directory
+// named elem, following the file path rules of modules, or an empty string if
+// it can be embedded.
+func badEmbedName(elem string) string {
+
+	if !utf8.ValidString(elem) {
+		return "is not valid UTF-8"
+	}
+
+	if strings.Count(elem, ".") == len(elem) {
+		return "is not a valid name"
+	}
+
+	if strings.HasSuffix(elem, ".") {
+		return "ends with a dot"
+	}
+
+	switch elem {
+	case ".bzr", ".hg", ".git", ".svn":
+		return "is a version control directory"
+	}
+
+	for _, r := range elem {
+
+		if r < utf8.RuneSelf {
+
+			// ASCII punctuation other than this, i.e. " ' : < > | and control characters, is refused
+			if '0' <= r && r <= '9' || 'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z' || strings.ContainsRune("!#$%&()+,-.=@[]^_{}~ ", r) {
+				continue
+			}
+
+			return fmt.Sprintf("contains the character %q", r)
+		}
+
+		if !unicode.IsLetter(r) {
+			return fmt.Sprintf("contains the character %q", r)
+		}
+	}
+
+	short := elem
+
+	if i := strings.Index(short, "."); i != -1 {
+		short = short[:i]
+	}
+
+	if windowsNames[strings.ToUpper(short)] {
+		return "is a reserved name on Windows"
+	}
+
+	return ""
+}
+
+// embedPattern returns name as a go:embed pattern, quoted when it contains
+// spaces; badEmbedName already refused every other character requiring it.
+func embedPattern(name string) string {
+
+	if strings.Contains(name, " ") {
+		return strconv.Quote(name)
+	}
+
+	return name
+}
+
+// within returns true if the relative path rel doesn't leave its base directory
+func within(rel string) bool {
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) && !filepath.IsAbs(rel)
+}
+
+// generateEmbed writes the go:embed directive file
+func (g *generator) generateEmbed(funcName, command string) {
+
+	std := []string{"embed"}
+
+	if g.embedRoot != "." {
+		std = append(std, "io/fs")
+	}
+
+	imports := g.importDecl(true, std...)
+	caller, assign := g.location()
+
+	directives := ""
+
+	for _, pattern := range g.embeds {
+		directives += "//go:embed " + pattern + "\n"
+	}
+
+	ret := fmt.Sprintf(embedReturn, funcName)
+
+	if g.embedRoot != "." {
+		ret = fmt.Sprintf(embedSub, funcName, g.embedRoot)
+	}
+
+	fmt.Fprintf(g.writer, embedFile, command, digestPrefix, g.digest, g.opts.Pkg, imports, directives, funcName, funcName, funcName, caller, assign, ret)
+}
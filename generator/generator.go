@@ -0,0 +1,932 @@
+// Package generator generates the go source files, embedding a directories files,
+// which are read by package static. It is used by the statics command but can also
+// be called in-process by build tools and tests.
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/statics/internal/gomod"
+)
+
+const (
+	// base64 line length of the compressed file contents
+	lineLength = 80
+
+	// files larger than this are never compressed ahead by the workers, they are
+	// streamed straight to the output when reached to keep memory use bounded
+	parallelMaxSize = 8 << 20
+)
+
+// Options contains information about what and how the static files should be generated
+type Options struct {
+	StaticDir  string    // the directory to embed
+	OutputFile string    // the output file, recorded in the go:generate command
+	Pkg        string    // package name of the generated file
+	Group      string    // the group name of the static files i.e. CSS, JS, Assets, HTML
+	Ignore     string    // optional regexp for files/dirs to ignore i.e. \.gitignore
+	Prefix     string    // optional prefix to strip from file paths
+	Init       bool      // only initializing the static file without contents
+	Force      bool      // always regenerate, even when the inputs are unchanged
+	BuildTags  bool      // emit the embedded files behind a !dev build tag and a disk backed file behind dev
+	Embed      bool      // embed the files using go:embed directives rather than generated data
+	Constants  bool      // emit a constant for every file path and a sorted list of all of them
+	Accessor   string    // optional name of an exported accessor lazily initializing the files on first use
+	Jobs       int       // number of files compressed in parallel, 0 or 1 compresses serially
+	Progress   io.Writer // optional writer each processed path is reported to
+}
+
+type generator struct {
+	ctx       context.Context
+	opts      Options
+	ignore    *regexp.Regexp
+	writer    *bufio.Writer
+	root      *entry
+	digest    string
+	embeds    []string // go:embed patterns of the files to embed
+	embedRoot string   // slash separated directory, relative to the output file, file paths are relative to
+	module    string   // module path of the go.mod the static files are within, empty when not in a module
+	modDir    string   // slash separated directory, relative to the module root, file paths are relative to
+}
+
+// entry is a file or directory to be embedded
+type entry struct {
+	path  string      // the path written to the generated file
+	src   string      // the path the contents are read from
+	name  string      // the file name, of the symlink when symlinked
+	info  os.FileInfo // the FileInfo, of the target when symlinked
+	files []*entry
+
+	// set when the contents are compressed ahead by a worker
+	compressed *compressed
+}
+
+// compressed holds the encoded contents of a file compressed ahead by a worker
+type compressed struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// Generate writes the go source for the static files described by opts to w.
+//
+// Each file is streamed from disk through the compressor and encoder straight
+// to w, so memory use is bounded regardless of file size, and the source is
+// written already gofmt formatted. Should generation fail w will contain partial
+// output, use GenerateFile to only replace an output file on success.
+func Generate(ctx context.Context, opts *Options, w io.Writer) error {
+
+	g, err := newGenerator(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if err = g.prepare(); err != nil {
+		return err
+	}
+
+	return g.write(w)
+}
+
+// GenerateFile generates the static files described by opts and writes them to
+// opts.OutputFile. The output is written to a temporary file in the same directory
+// and renamed into place only once generation succeeds, so on error any previous
+// output file is left untouched.
+//
+// Unless opts.Force is set, generation is skipped, leaving the output file and
+// its modification time intact, when the digest of the inputs and options
+// recorded in the output file is unchanged.
+//
+// When opts.BuildTags is set the disk backed file for the dev build tag is also
+// written, next to the output file with a _dev suffix i.e. assets_dev.go, once
+// the output file is up to date.
+func GenerateFile(ctx context.Context, opts *Options) error {
+
+	if len(opts.OutputFile) == 0 {
+		return errors.New("invalid Output File")
+	}
+
+	g, err := newGenerator(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if err = g.prepare(); err != nil {
+		return err
+	}
+
+	if !opts.Force && len(g.digest) > 0 && readDigest(opts.OutputFile) == g.digest {
+
+		if opts.Progress != nil {
+			fmt.Fprintln(opts.Progress, "Up to date:", opts.OutputFile)
+		}
+
+	} else if err = writeFileAtomic(opts.OutputFile, g.write); err != nil {
+		return err
+	}
+
+	// only once the output file is current, so both builds always declare the same
+	if opts.BuildTags {
+		return g.writeDevFile()
+	}
+
+	return nil
+}
+
+// writeFileAtomic calls fn with a temporary file in the same directory as name
+// and renames it to name once fn and closing the file succeed.
+func writeFileAtomic(name string, fn func(w io.Writer) error) (err error) {
+
+	mode := os.FileMode(0644)
+
+	if fi, err := os.Stat(name); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if err = fn(f); err != nil {
+		return err
+	}
+
+	if err = f.Chmod(mode); err != nil {
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
+}
+
+func newGenerator(ctx context.Context, opts *Options) (*generator, error) {
+
+	g := &generator{
+		ctx:  ctx,
+		opts: *opts,
+	}
+
+	if err := g.parseOptions(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func (g *generator) parseOptions() error {
+
+	g.opts.StaticDir = filepath.Clean(g.opts.StaticDir)
+
+	if len(g.opts.StaticDir) == 0 || g.opts.StaticDir == "." {
+		return errors.New("invalid Static File Directoy '" + g.opts.StaticDir + "'")
+	}
+
+	if len(g.opts.OutputFile) == 0 {
+		return errors.New("invalid Output File")
+	}
+
+	if len(g.opts.Pkg) == 0 {
+		return errors.New("invalid Package Name")
+	}
+
+	if !token.IsIdentifier(g.opts.Pkg) || g.opts.Pkg == "_" {
+		return errors.New("invalid Package Name '" + g.opts.Pkg + "', it must be a Go identifier")
+	}
+
+	if len(g.opts.Group) == 0 {
+		return errors.New("invalid Group Name")
+	}
+
+	if !token.IsIdentifier(g.opts.Group) {
+		return errors.New("invalid Group Name '" + g.opts.Group + "', it must be a Go identifier")
+	}
+
+	if len(g.opts.Accessor) > 0 && (!token.IsIdentifier(g.opts.Accessor) || !token.IsExported(g.opts.Accessor)) {
+		return errors.New("invalid Accessor Name '" + g.opts.Accessor + "', it must be an exported Go identifier")
+	}
+
+	if g.opts.Constants && g.opts.Accessor == g.funcName()+"Paths" {
+		return errors.New("invalid Accessor Name '" + g.opts.Accessor + "', it is the name of the generated path list")
+	}
+
+	if len(g.opts.Ignore) > 0 {
+
+		var err error
+
+		g.ignore, err = regexp.Compile(g.opts.Ignore)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepare locates the module, walks the static directory and computes the digest
+// of the inputs
+func (g *generator) prepare() (err error) {
+
+	g.module, g.modDir = g.locate()
+
+	if g.opts.Init {
+		return nil
+	}
+
+	if g.root, err = g.walk(); err != nil {
+		return err
+	}
+
+	if g.opts.Embed {
+
+		if err = g.prepareEmbed(); err != nil {
+			return err
+		}
+	}
+
+	g.digest, err = g.computeDigest()
+
+	return err
+}
+
+// write writes the generated source to w
+func (g *generator) write(w io.Writer) error {
+
+	g.writer = bufio.NewWriter(w)
+
+	if err := g.generate(); err != nil {
+		return err
+	}
+
+	return g.writer.Flush()
+}
+
+// writeDevFile writes the disk backed file for the dev build tag, unless the
+// existing one is already identical
+func (g *generator) writeDevFile() error {
+
+	name := devFileName(g.opts.OutputFile)
+
+	src, err := g.devSource()
+	if err != nil {
+		return err
+	}
+
+	if b, err := ioutil.ReadFile(name); err == nil && bytes.Equal(b, src) {
+		return nil
+	}
+
+	return writeFileAtomic(name, func(w io.Writer) error {
+		_, err := w.Write(src)
+		return err
+	})
+}
+
+// devSource returns the source of the disk backed file for the dev build tag
+func (g *generator) devSource() ([]byte, error) {
+
+	funcName := g.funcName()
+
+	var buff bytes.Buffer
+
+	g.writer = bufio.NewWriter(&buff)
+
+	caller, assign := g.location()
+
+	fmt.Fprintf(g.writer, devFile, g.command(), g.opts.Pkg, g.importDecl(true), funcName, funcName, caller, assign)
+	g.writeAccessor(funcName)
+
+	// the paths must be declared in both builds for code using them to compile
+	if g.opts.Constants && !g.opts.Init {
+		g.writeConstants(funcName)
+	}
+
+	if err := g.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
+
+// locate returns the module path and directory, relative to the module root, the
+// embedded file paths are relative to; so the runtime can resolve AbsPkgPath from
+// the module rather than requiring a hand computed absolute path.
+func (g *generator) locate() (module string, dir string) {
+
+	base, err := g.base()
+	if err != nil {
+		return "", ""
+	}
+
+	for root := base; ; {
+
+		if module = gomod.ModulePath(filepath.Join(root, "go.mod")); len(module) > 0 {
+
+			rel, err := filepath.Rel(root, base)
+			if err != nil {
+				return "", ""
+			}
+
+			if rel == "." {
+				rel = ""
+			}
+
+			return module, filepath.ToSlash(rel)
+		}
+
+		parent := filepath.Dir(root)
+		if parent == root {
+			return "", ""
+		}
+
+		root = parent
+	}
+}
+
+// base returns the absolute directory the embedded file paths are relative to
+func (g *generator) base() (string, error) {
+
+	base := g.opts.Prefix
+
+	if len(base) == 0 {
+
+		base = "."
+
+		if filepath.IsAbs(g.opts.StaticDir) {
+			base = string(os.PathSeparator)
+		}
+	}
+
+	return filepath.Abs(base)
+}
+
+// devFileName returns the name of the dev build tag file for output i.e.
+// assets.go => assets_dev.go
+func devFileName(output string) string {
+
+	ext := filepath.Ext(output)
+
+	return strings.TrimSuffix(output, ext) + "_dev" + ext
+}
+
+// importDecl returns the import declaration of the static package and the
+// standard library packages std, plus those the location and accessor require
+func (g *generator) importDecl(location bool, std ...string) string {
+
+	if location && len(g.module) > 0 {
+		std = append(std, "runtime")
+	}
+
+	if len(g.opts.Accessor) > 0 {
+		std = append(std, "sync")
+	}
+
+	if len(std) == 0 {
+		return `import "github.com/go-playground/statics/static"`
+	}
+
+	sort.Strings(std)
+
+	decl := "import (\n"
+
+	for _, pkg := range std {
+		decl += "\t\"" + pkg + "\"\n"
+	}
+
+	return decl + "\n\t\"github.com/go-playground/statics/static\"\n)"
+}
+
+// location returns the source recording the module location in the generated
+// constructor, empty when not within a module
+func (g *generator) location() (caller string, assign string) {
+
+	if len(g.module) == 0 {
+		return "", ""
+	}
+
+	return locationCaller, fmt.Sprintf(locationAssign, g.module, g.modDir)
+}
+
+// writeAccessor writes the exported lazily initialized accessor, if any
+func (g *generator) writeAccessor(funcName string) {
+
+	if len(g.opts.Accessor) > 0 {
+		fmt.Fprintf(g.writer, accessorFuncs, g.opts.Accessor, funcName)
+	}
+}
+
+// funcName returns the name of the generated constructor suffix
+func (g *generator) funcName() string {
+
+	r, size := utf8.DecodeRuneInString(g.opts.Group)
+
+	return string(unicode.ToUpper(r)) + g.opts.Group[size:]
+}
+
+// command returns the arguments recorded in the go:generate command, quoted and
+// escaped so go generate reproduces the exact invocation
+func (g *generator) command() string {
+
+	args := []string{
+		"-i=" + g.opts.StaticDir,
+		"-o=" + g.opts.OutputFile,
+		"-pkg=" + g.opts.Pkg,
+		"-group=" + g.opts.Group,
+	}
+
+	if len(g.opts.Ignore) > 0 {
+		args = append(args, "-ignore="+g.opts.Ignore)
+	}
+
+	if len(g.opts.Prefix) > 0 {
+		args = append(args, "-prefix="+g.opts.Prefix)
+	}
+
+	if g.opts.BuildTags {
+		args = append(args, "-buildtags=true")
+	}
+
+	if g.opts.Embed {
+		args = append(args, "-embed=true")
+	}
+
+	if g.opts.Constants {
+		args = append(args, "-constants=true")
+	}
+
+	if len(g.opts.Accessor) > 0 {
+		args = append(args, "-accessor="+g.opts.Accessor)
+	}
+
+	for i, arg := range args {
+		args[i] = generateArg(arg)
+	}
+
+	return strings.Join(args, " ")
+}
+
+// generateArg returns arg as a go:generate argument. go generate splits arguments
+// on spaces, unquotes those starting with a double quote and then expands
+// environment variables, so arg is quoted when required and any $ which would
+// be expanded is replaced with ${DOLLAR}, which go generate expands to $.
+func generateArg(arg string) string {
+
+	var escaped strings.Builder
+
+	for i := 0; i < len(arg); i++ {
+
+		if arg[i] == '$' && i+1 < len(arg) && expands(arg[i+1]) {
+			escaped.WriteString("${DOLLAR}")
+			continue
+		}
+
+		escaped.WriteByte(arg[i])
+	}
+
+	arg = escaped.String()
+
+	if strings.HasPrefix(arg, `"`) || !utf8.ValidString(arg) {
+		return strconv.Quote(arg)
+	}
+
+	for _, r := range arg {
+
+		if unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(arg)
+		}
+	}
+
+	return arg
+}
+
+// expands returns true if c following a $ is expanded by os.Expand
+func expands(c byte) bool {
+	return c == '_' || c == '{' || strings.IndexByte("*#$@!?-", c) != -1 ||
+		'0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func (g *generator) generate() error {
+
+	funcName := g.funcName()
+	command := g.command()
+
+	if g.opts.BuildTags {
+		g.writer.WriteString(prodBuildTag)
+	}
+
+	if g.opts.Init {
+		fmt.Fprintf(g.writer, initFile, command, g.opts.Pkg, g.importDecl(false), funcName, funcName)
+		g.writeAccessor(funcName)
+		return nil
+	}
+
+	if g.opts.Embed {
+
+		g.generateEmbed(funcName, command)
+		g.writeAccessor(funcName)
+
+		if g.opts.Constants {
+			g.writeConstants(funcName)
+		}
+
+		return nil
+	}
+
+	window, stop := g.startWorkers(g.root)
+	defer stop()
+
+	caller, assign := g.location()
+
+	fmt.Fprintf(g.writer, startFile, command, digestPrefix, g.digest, g.opts.Pkg, g.importDecl(true, "os"), funcName, funcName, caller, assign)
+
+	if err := g.writeEntry(g.root, 2, window); err != nil {
+		return err
+	}
+
+	g.writer.WriteString(endFile)
+	g.writeAccessor(funcName)
+
+	if g.opts.Constants {
+		g.writeConstants(funcName)
+	}
+
+	return nil
+}
+
+// walk returns the tree of files and directories to embed, without their contents
+func (g *generator) walk() (*entry, error) {
+
+	fi, err := os.Stat(g.opts.StaticDir)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &entry{
+		path: g.applyPathOptions(g.opts.StaticDir),
+		src:  g.opts.StaticDir,
+		name: fi.Name(),
+		info: fi,
+	}
+
+	root.files, err = g.walkRecursive(g.opts.StaticDir, "", false, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// need isSymlinkDir variable as it is valid for symlinkDir to be blank
+func (g *generator) walkRecursive(path string, dir string, isSymlinkDir bool, symlinkDir string) ([]*entry, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := f.Readdir(0)
+	f.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	entries := make([]*entry, 0, len(files))
+
+	for _, file := range files {
+
+		if err = g.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		p := path + string(os.PathSeparator) + file.Name()
+		fPath := p
+
+		if isSymlinkDir {
+			fPath = strings.Replace(p, dir, symlinkDir, 1)
+		}
+
+		if g.ignore != nil && g.ignore.MatchString(fPath) {
+			continue
+		}
+
+		e := &entry{
+			path: g.applyPathOptions(fPath),
+			src:  p,
+			name: file.Name(),
+			info: file,
+		}
+
+		entries = append(entries, e)
+
+		if file.IsDir() {
+
+			if e.files, err = g.walkRecursive(p, p, isSymlinkDir, symlinkDir+string(os.PathSeparator)+file.Name()); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if file.Mode()&os.ModeSymlink == os.ModeSymlink {
+
+			link, err := filepath.EvalSymlinks(p)
+			if err != nil {
+				return nil, fmt.Errorf("Error Resolving Symlink %s", err)
+			}
+
+			if e.info, err = os.Stat(link); err != nil {
+				return nil, err
+			}
+
+			e.src = link
+
+			if e.info.IsDir() {
+
+				if e.files, err = g.walkRecursive(link, link, true, fPath); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// startWorkers compresses the files of root ahead of them being written using
+// opts.Jobs workers. Files are queued in output order and at most window
+// compressed results are held in memory at once, the writer releasing a slot of
+// the returned window after writing each one. stop must be called once done.
+func (g *generator) startWorkers(root *entry) (window chan struct{}, stop func()) {
+
+	if g.opts.Jobs <= 1 {
+		return nil, func() {}
+	}
+
+	var queue []*entry
+
+	var collect func(e *entry)
+	collect = func(e *entry) {
+
+		if !e.info.IsDir() && e.info.Size() <= parallelMaxSize {
+			e.compressed = &compressed{done: make(chan struct{})}
+			queue = append(queue, e)
+		}
+
+		for _, nested := range e.files {
+			collect(nested)
+		}
+	}
+
+	collect(root)
+
+	ctx, cancel := context.WithCancel(g.ctx)
+	window = make(chan struct{}, g.opts.Jobs*2)
+	jobs := make(chan *entry)
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+
+		for _, e := range queue {
+
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case jobs <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < g.opts.Jobs; i++ {
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for e := range jobs {
+
+				var buff bytes.Buffer
+
+				e.compressed.err = encode(e.src, &buff)
+				e.compressed.data = buff.Bytes()
+				close(e.compressed.done)
+			}
+		}()
+	}
+
+	return window, func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// writeEntry writes e, and any files it contains, as a static.DirFile composite
+// literal whose fields are indented by depth tabs.
+func (g *generator) writeEntry(e *entry, depth int, window chan struct{}) error {
+
+	if err := g.ctx.Err(); err != nil {
+		return err
+	}
+
+	if depth > 2 {
+		g.progress(e.path)
+	}
+
+	indent := strings.Repeat("\t", depth)
+
+	fmt.Fprintf(g.writer, dirFileFields, indent, e.path, indent, e.name, indent, e.info.Size(), indent, e.info.Mode(), indent, e.info.ModTime().Unix(), indent, e.info.IsDir(), indent)
+
+	if !e.info.IsDir() {
+		if err := g.writeCompressed(e, window); err != nil {
+			return err
+		}
+	}
+
+	g.writer.WriteString("`,\n" + indent + "Files: []*static.DirFile{")
+
+	for i, nested := range e.files {
+
+		if i == 0 {
+			g.writer.WriteString("{\n")
+		} else {
+			g.writer.WriteString(indent + "}, {\n")
+		}
+
+		if err := g.writeEntry(nested, depth+1, window); err != nil {
+			return err
+		}
+	}
+
+	if len(e.files) > 0 {
+		g.writer.WriteString(indent + "}")
+	}
+
+	g.writer.WriteString("},\n")
+
+	return nil
+}
+
+// writeCompressed writes the encoded contents of e, waiting for a worker if
+// compressed ahead otherwise streaming it straight to the output.
+func (g *generator) writeCompressed(e *entry, window chan struct{}) error {
+
+	if e.compressed == nil {
+		return encode(e.src, g.writer)
+	}
+
+	select {
+	case <-e.compressed.done:
+	case <-g.ctx.Done():
+		return g.ctx.Err()
+	}
+
+	<-window
+
+	if e.compressed.err != nil {
+		return e.compressed.err
+	}
+
+	_, err := g.writer.Write(e.compressed.data)
+
+	return err
+}
+
+// encode streams the file at path through gzip and base64 to w, split into
+// lines of lineLength
+func encode(path string, w io.Writer) error {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	lw := &lineWriter{w: w}
+	b64 := base64.NewEncoder(base64.StdEncoding, lw)
+	gz := gzip.NewWriter(b64)
+
+	if _, err = io.Copy(gz, f); err != nil {
+		return err
+	}
+
+	// Flush not quaranteed to flush, must close
+	if err = gz.Close(); err != nil {
+		return err
+	}
+
+	if err = b64.Close(); err != nil {
+		return err
+	}
+
+	return lw.Close()
+}
+
+func (g *generator) progress(path string) {
+
+	if g.opts.Progress != nil {
+		fmt.Fprintln(g.opts.Progress, "Processing:", path)
+	}
+}
+
+func (g *generator) applyPathOptions(path string) string {
+	path = strings.TrimPrefix(path, g.opts.Prefix)
+	path = strings.TrimLeft(path, string(os.PathSeparator))
+	return string(os.PathSeparator) + path
+}
+
+// lineWriter writes a newline after every lineLength bytes written through it
+type lineWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+
+	var written int
+
+	for len(p) > 0 {
+
+		chunk := p
+
+		if len(chunk) > lineLength-l.n {
+			chunk = chunk[:lineLength-l.n]
+		}
+
+		n, err := l.w.Write(chunk)
+		written += n
+		l.n += n
+
+		if err != nil {
+			return written, err
+		}
+
+		if l.n == lineLength {
+
+			if _, err = l.w.Write([]byte{'\n'}); err != nil {
+				return written, err
+			}
+
+			l.n = 0
+		}
+
+		p = p[n:]
+	}
+
+	return written, nil
+}
+
+// Close terminates any partial last line
+func (l *lineWriter) Close() error {
+
+	if l.n == 0 {
+		return nil
+	}
+
+	l.n = 0
+
+	_, err := l.w.Write([]byte{'\n'})
+
+	return err
+}
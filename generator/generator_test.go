@@ -0,0 +1,1424 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+// NOTES:
+// - Run "go test" to run tests
+// - Run "gocov test | gocov report" to report on test converage by file
+// - Run "gocov test | gocov annotate -" to report on all code and functions, those ,marked with "MISS" were never called
+//
+// or
+//
+// -- may be a good idea to change to output path to somewherelike /tmp
+// go test -coverprofile cover.out && go tool cover -html=cover.out -o cover.html
+//
+
+func testOptions() *Options {
+	return &Options{
+		StaticDir:  "../static/test-files/teststart",
+		OutputFile: "assets.go",
+		Pkg:        "test",
+		Group:      "assets",
+		Prefix:     "../",
+	}
+}
+
+func TestGenerate(t *testing.T) {
+
+	var buff bytes.Buffer
+	var progress bytes.Buffer
+
+	opts := testOptions()
+	opts.Progress = &progress
+
+	err := Generate(context.Background(), opts, &buff)
+	Equal(t, err, nil)
+
+	s := buff.String()
+	Equal(t, strings.HasPrefix(s, "//go:generate statics -i=../static/test-files/teststart -o=assets.go -pkg=test -group=assets -prefix=../\n// statics:digest sha256:"), true)
+	Equal(t, strings.Contains(s, "\n\npackage test\n"), true)
+	Equal(t, strings.Contains(s, "func newStaticAssets(config *static.Config) (*static.Files, error) {"), true)
+	Equal(t, strings.Contains(s, "\treturn static.New(&c, &static.DirFile{\n\t\tPath:    \"/static/test-files/teststart\",\n"), true)
+	Equal(t, strings.Contains(s, "\t\tFiles: []*static.DirFile{{\n\t\t\tPath:    \"/static/test-files/teststart/"), true)
+	Equal(t, strings.Contains(s, `Path:    "/static/test-files/teststart/symlinkeddir/realdir/doublesymlinkeddir/triplesymlinkeddir/triplefile.txt",`), true)
+	Equal(t, strings.Contains(progress.String(), "Processing: /static/test-files/teststart/plainfile.txt\n"), true)
+}
+
+func TestGenerateFormatted(t *testing.T) {
+
+	var buff bytes.Buffer
+
+	err := Generate(context.Background(), testOptions(), &buff)
+	Equal(t, err, nil)
+
+	b, err := format.Source(buff.Bytes())
+	Equal(t, err, nil)
+	Equal(t, string(b), buff.String())
+
+	buff.Reset()
+
+	opts := testOptions()
+	opts.Init = true
+
+	err = Generate(context.Background(), opts, &buff)
+	Equal(t, err, nil)
+
+	b, err = format.Source(buff.Bytes())
+	Equal(t, err, nil)
+	Equal(t, string(b), buff.String())
+}
+
+func TestGenerateLargeFile(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("skipping large file generation in short mode")
+	}
+
+	const size = 512 << 20
+
+	dir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(dir, "large.bin"))
+	Equal(t, err, nil)
+
+	// sparse, takes no space on disk
+	Equal(t, f.Truncate(size), nil)
+	Equal(t, f.Close(), nil)
+
+	opts := testOptions()
+	opts.StaticDir = dir
+	opts.Prefix = dir
+
+	var before, after runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	err = Generate(context.Background(), opts, ioutil.Discard)
+	Equal(t, err, nil)
+
+	runtime.ReadMemStats(&after)
+
+	// everything allocated during generation is a small fraction of the file size
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/16 {
+		t.Fatalf("generating a %d byte file allocated %d bytes", size, allocated)
+	}
+}
+
+func TestGenerateParallel(t *testing.T) {
+
+	dir := t.TempDir()
+
+	for i := 0; i < 50; i++ {
+
+		sub := filepath.Join(dir, fmt.Sprintf("dir%d", i%5))
+
+		Equal(t, os.MkdirAll(sub, 0755), nil)
+		Equal(t, ioutil.WriteFile(filepath.Join(sub, fmt.Sprintf("file%d.txt", i)), bytes.Repeat([]byte(fmt.Sprintf("contents %d\n", i)), i*100), 0644), nil)
+	}
+
+	// larger than is compressed ahead, streamed inline between compressed files
+	f, err := os.Create(filepath.Join(dir, "dir2", "large.bin"))
+	Equal(t, err, nil)
+	Equal(t, f.Truncate(parallelMaxSize+1), nil)
+	Equal(t, f.Close(), nil)
+
+	opts := testOptions()
+	opts.StaticDir = dir
+	opts.Prefix = dir
+
+	var serial, parallel, serialProgress, parallelProgress bytes.Buffer
+
+	opts.Jobs = 1
+	opts.Progress = &serialProgress
+
+	err = Generate(context.Background(), opts, &serial)
+	Equal(t, err, nil)
+
+	opts.Jobs = 8
+	opts.Progress = &parallelProgress
+
+	err = Generate(context.Background(), opts, &parallel)
+	Equal(t, err, nil)
+	Equal(t, parallel.String(), serial.String())
+	Equal(t, parallelProgress.String(), serialProgress.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = Generate(ctx, opts, ioutil.Discard)
+	Equal(t, err, context.Canceled)
+}
+
+func BenchmarkGenerate(b *testing.B) {
+
+	dir := b.TempDir()
+	contents := make([]byte, 64<<10)
+
+	for i := 0; i < 500; i++ {
+
+		sub := filepath.Join(dir, fmt.Sprintf("dir%d", i%10))
+
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			b.Fatal(err)
+		}
+
+		// compressible but not trivially so
+		for j := range contents {
+			contents[j] = byte((i * j) % 251)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(sub, fmt.Sprintf("file%d.bin", i)), contents, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	parallel := runtime.NumCPU()
+
+	if parallel < 2 {
+		parallel = 2
+	}
+
+	for _, jobs := range []int{1, parallel} {
+
+		opts := testOptions()
+		opts.StaticDir = dir
+		opts.Prefix = dir
+		opts.Jobs = jobs
+
+		name := "serial"
+
+		if jobs > 1 {
+			name = fmt.Sprintf("parallel-%d", jobs)
+		}
+
+		b.Run(name, func(b *testing.B) {
+
+			for i := 0; i < b.N; i++ {
+
+				if err := Generate(context.Background(), opts, ioutil.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateInit(t *testing.T) {
+
+	var buff bytes.Buffer
+
+	opts := testOptions()
+	opts.Init = true
+
+	err := Generate(context.Background(), opts, &buff)
+	Equal(t, err, nil)
+	Equal(t, strings.Contains(buff.String(), "\treturn static.New(config, &static.DirFile{})\n}\n"), true)
+	Equal(t, strings.Contains(buff.String(), "plainfile.txt"), false)
+}
+
+func TestGenerateIgnore(t *testing.T) {
+
+	var buff bytes.Buffer
+
+	opts := testOptions()
+	opts.Ignore = ".*.txt"
+
+	err := Generate(context.Background(), opts, &buff)
+	Equal(t, err, nil)
+	Equal(t, strings.Contains(buff.String(), ".txt\","), false)
+	Equal(t, strings.Contains(buff.String(), `Path:    "/static/test-files/teststart/symlinkeddir/realdir",`), true)
+}
+
+func TestGenerateErrors(t *testing.T) {
+
+	var buff bytes.Buffer
+
+	opts := testOptions()
+	opts.StaticDir = "../static/test-files/garbagedir"
+
+	err := Generate(context.Background(), opts, &buff)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "stat ../static/test-files/garbagedir: no such file or directory")
+
+	opts = testOptions()
+	opts.StaticDir = ""
+
+	err = Generate(context.Background(), opts, &buff)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "invalid Static File Directoy '.'")
+
+	opts = testOptions()
+	opts.Pkg = ""
+
+	err = Generate(context.Background(), opts, &buff)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "invalid Package Name")
+
+	for _, pkg := range []string{"my-pkg", "func", "_", "1pkg"} {
+
+		opts.Pkg = pkg
+
+		err = Generate(context.Background(), opts, &buff)
+		NotEqual(t, err, nil)
+		Equal(t, err.Error(), "invalid Package Name '"+pkg+"', it must be a Go identifier")
+	}
+
+	opts = testOptions()
+	opts.Group = ""
+
+	err = Generate(context.Background(), opts, &buff)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "invalid Group Name")
+
+	for _, group := range []string{"my assets", "type", "\"Assets", "a.b"} {
+
+		opts.Group = group
+
+		err = Generate(context.Background(), opts, &buff)
+		NotEqual(t, err, nil)
+		Equal(t, err.Error(), "invalid Group Name '"+group+"', it must be a Go identifier")
+	}
+
+	opts = testOptions()
+	opts.Ignore = "([12.gitignore"
+
+	err = Generate(context.Background(), opts, &buff)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "error parsing regexp: missing closing ]: `[12.gitignore`")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = Generate(ctx, testOptions(), &buff)
+	Equal(t, err, context.Canceled)
+}
+
+func TestGenerateFileAtomic(t *testing.T) {
+
+	dir := t.TempDir()
+
+	opts := testOptions()
+	opts.OutputFile = filepath.Join(dir, "assets.go")
+
+	err := GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	expected, err := ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, strings.Contains(string(expected), "plainfile.txt"), true)
+
+	fi, err := os.Stat(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, fi.Mode().Perm(), os.FileMode(0644))
+
+	// failing midway leaves the previous output untouched
+	opts.StaticDir = "../static/test-files/garbagedir"
+
+	err = GenerateFile(context.Background(), opts)
+	NotEqual(t, err, nil)
+
+	b, err := ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, string(b), string(expected))
+
+	files, err := ioutil.ReadDir(dir)
+	Equal(t, err, nil)
+	Equal(t, len(files), 1)
+
+	opts = testOptions()
+	opts.OutputFile = filepath.Join(dir, "nonexistantdir", "assets.go")
+
+	err = GenerateFile(context.Background(), opts)
+	NotEqual(t, err, nil)
+}
+
+func TestGenerateFileIncremental(t *testing.T) {
+
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+	appJS := filepath.Join(assets, "app.js")
+
+	Equal(t, os.MkdirAll(assets, 0755), nil)
+	Equal(t, ioutil.WriteFile(appJS, []byte("alert(1)"), 0644), nil)
+
+	var progress bytes.Buffer
+
+	opts := testOptions()
+	opts.StaticDir = assets
+	opts.Prefix = dir
+	opts.OutputFile = filepath.Join(dir, "assets.go")
+	opts.Progress = &progress
+
+	err := GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	Equal(t, os.Chtimes(opts.OutputFile, old, old), nil)
+
+	expected := readDigest(opts.OutputFile)
+	NotEqual(t, expected, "")
+
+	// unchanged inputs leave the output file untouched
+	err = GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, strings.Contains(progress.String(), "Up to date: "+opts.OutputFile+"\n"), true)
+
+	fi, err := os.Stat(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, fi.ModTime().Equal(old), true)
+
+	// force always regenerates
+	opts.Force = true
+
+	err = GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	fi, err = os.Stat(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, fi.ModTime().Equal(old), false)
+	Equal(t, readDigest(opts.OutputFile), expected)
+
+	// changed contents of the same size and modification time are detected
+	opts.Force = false
+
+	info, err := os.Stat(appJS)
+	Equal(t, err, nil)
+	Equal(t, ioutil.WriteFile(appJS, []byte("alert(2)"), 0644), nil)
+	Equal(t, os.Chtimes(appJS, info.ModTime(), info.ModTime()), nil)
+
+	err = GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+	NotEqual(t, readDigest(opts.OutputFile), expected)
+
+	// as are changed options
+	expected = readDigest(opts.OutputFile)
+	opts.Pkg = "other"
+
+	err = GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+	NotEqual(t, readDigest(opts.OutputFile), expected)
+
+	b, err := ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, strings.Contains(string(b), "\npackage other\n"), true)
+}
+
+func TestGenerateBuildTags(t *testing.T) {
+
+	dir := t.TempDir()
+
+	opts := testOptions()
+	opts.OutputFile = filepath.Join(dir, "assets.go")
+	opts.BuildTags = true
+
+	err := GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	prod, err := ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, strings.HasPrefix(string(prod), "//go:build !dev\n\n//go:generate statics -i=../static/test-files/teststart -o="+opts.OutputFile+" -pkg=test -group=assets -prefix=../ -buildtags=true\n"), true)
+	Equal(t, strings.Contains(string(prod), "plainfile.txt"), true)
+
+	dev, err := ioutil.ReadFile(filepath.Join(dir, "assets_dev.go"))
+	Equal(t, err, nil)
+	Equal(t, strings.HasPrefix(string(dev), "//go:build dev\n\n//go:generate statics -i=../static/test-files/teststart -o="+opts.OutputFile+" -pkg=test -group=assets -prefix=../ -buildtags=true\n"), true)
+	Equal(t, strings.Contains(string(dev), "func newStaticAssets(config *static.Config) (*static.Files, error) {"), true)
+	Equal(t, strings.Contains(string(dev), "plainfile.txt"), false)
+
+	for _, b := range [][]byte{prod, dev} {
+		formatted, err := format.Source(b)
+		Equal(t, err, nil)
+		Equal(t, string(formatted), string(b))
+	}
+
+	// exactly one of the pair is built, depending on the dev tag
+	ctx := build.Default
+
+	match, err := ctx.MatchFile(dir, "assets.go")
+	Equal(t, err, nil)
+	Equal(t, match, true)
+
+	match, err = ctx.MatchFile(dir, "assets_dev.go")
+	Equal(t, err, nil)
+	Equal(t, match, false)
+
+	ctx.BuildTags = []string{"dev"}
+
+	match, err = ctx.MatchFile(dir, "assets.go")
+	Equal(t, err, nil)
+	Equal(t, match, false)
+
+	match, err = ctx.MatchFile(dir, "assets_dev.go")
+	Equal(t, err, nil)
+	Equal(t, match, true)
+
+	Equal(t, devFileName("assets.go"), "assets_dev.go")
+	Equal(t, devFileName(filepath.Join("a.b", "assets")), filepath.Join("a.b", "assets_dev"))
+
+	// when generating the output file fails the dev file is left as is, so the
+	// pair never declare different functions i.e. an accessor in only one
+	ctx2, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts.Accessor = "Assets"
+	opts.Force = true
+	opts.Progress = cancelWriter(cancel)
+
+	err = GenerateFile(ctx2, opts)
+	Equal(t, err, context.Canceled)
+
+	b, err := ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, string(b), string(prod))
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "assets_dev.go"))
+	Equal(t, err, nil)
+	Equal(t, string(b), string(dev))
+
+	opts.Progress = nil
+
+	err = GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	for _, name := range []string{"assets.go", "assets_dev.go"} {
+		b, err = ioutil.ReadFile(filepath.Join(dir, name))
+		Equal(t, err, nil)
+		Equal(t, strings.Contains(string(b), "func Assets() (*static.Files, error) {"), true)
+	}
+}
+
+// cancelWriter cancels generation as soon as progress is reported, once the
+// output file has started being written
+type cancelWriter context.CancelFunc
+
+func (c cancelWriter) Write(p []byte) (int, error) {
+	c()
+	return len(p), nil
+}
+
+func TestGenerateLocation(t *testing.T) {
+
+	root := t.TempDir()
+	web := filepath.Join(root, "web")
+
+	Equal(t, os.MkdirAll(filepath.Join(web, "assets"), 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/project\n\ngo 1.16\n"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(web, "assets", "app.js"), []byte("alert(1)"), 0644), nil)
+
+	opts := testOptions()
+	opts.StaticDir = filepath.Join(web, "assets")
+	opts.Prefix = web
+	opts.OutputFile = filepath.Join(web, "assets.go")
+	opts.BuildTags = true
+
+	err := GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	location := "\tif c.Location == nil {\n\t\tc.Location = &static.Location{Module: \"example.com/project\", Dir: \"web\", Source: source}\n\t}\n"
+
+	for _, name := range []string{opts.OutputFile, devFileName(opts.OutputFile)} {
+
+		b, err := ioutil.ReadFile(name)
+		Equal(t, err, nil)
+		Equal(t, strings.Contains(string(b), "\t_, source, _, _ := runtime.Caller(0)\n"), true)
+		Equal(t, strings.Contains(string(b), location), true)
+
+		formatted, err := format.Source(b)
+		Equal(t, err, nil)
+		Equal(t, string(formatted), string(b))
+	}
+
+	// outside of a module no location is recorded
+	outside := t.TempDir()
+
+	Equal(t, ioutil.WriteFile(filepath.Join(outside, "app.js"), []byte("alert(1)"), 0644), nil)
+
+	opts.StaticDir = outside
+	opts.Prefix = ""
+	opts.OutputFile = filepath.Join(outside, "assets.go")
+
+	err = GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	for _, name := range []string{opts.OutputFile, devFileName(opts.OutputFile)} {
+
+		b, err := ioutil.ReadFile(name)
+		Equal(t, err, nil)
+		Equal(t, strings.Contains(string(b), "runtime"), false)
+		Equal(t, strings.Contains(string(b), "c.Location"), false)
+
+		formatted, err := format.Source(b)
+		Equal(t, err, nil)
+		Equal(t, string(formatted), string(b))
+	}
+}
+
+func TestGenerateEmbed(t *testing.T) {
+
+	dir := t.TempDir()
+	web := filepath.Join(dir, "web")
+
+	Equal(t, os.MkdirAll(filepath.Join(web, "assets", "css"), 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(web, "assets", "css", "app.css"), []byte("body {}"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(web, "assets", "my app.js"), []byte("alert(1)"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(web, "assets", ".gitignore"), []byte("*.swp"), 0644), nil)
+
+	opts := testOptions()
+	opts.StaticDir = filepath.Join(web, "assets")
+	opts.Prefix = web
+	opts.Ignore = `\.gitignore$`
+	opts.OutputFile = filepath.Join(dir, "assets.go")
+	opts.Embed = true
+
+	err := GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	b, err := ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+
+	s := string(b)
+	Equal(t, strings.Contains(s, " -embed=true\n"), true)
+	Equal(t, strings.Contains(s, "\t\"embed\"\n\t\"io/fs\"\n"), true)
+	Equal(t, strings.Contains(s, "//go:embed web/assets/css/app.css\n//go:embed \"web/assets/my app.js\"\nvar staticAssetsFS embed.FS\n"), true)
+	Equal(t, strings.Contains(s, "assets/.gitignore"), false)
+	Equal(t, strings.Contains(s, "\tfsys, err := fs.Sub(staticAssetsFS, \"web\")\n"), true)
+	Equal(t, strings.Contains(s, "\treturn static.NewFromFS(&c, fsys)\n"), true)
+
+	formatted, err := format.Source(b)
+	Equal(t, err, nil)
+	Equal(t, string(formatted), s)
+
+	drift, err := Check(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, drift.HasDrift(), false)
+
+	Equal(t, ioutil.WriteFile(filepath.Join(web, "assets", "new.js"), []byte("alert(2)"), 0644), nil)
+
+	drift, err = Check(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, drift.Added, []string{"web/assets/new.js"})
+
+	// without a sub directory the embed.FS is used as is
+	opts.Prefix = ""
+	opts.StaticDir = filepath.Join(web, "assets")
+	opts.OutputFile = filepath.Join(web, "assets.go")
+
+	// an absolute static directory without a prefix is relative to the root
+	err = Generate(context.Background(), opts, ioutil.Discard)
+	NotEqual(t, err, nil)
+
+	wd, err := os.Getwd()
+	Equal(t, err, nil)
+	Equal(t, os.Chdir(web), nil)
+	defer os.Chdir(wd)
+
+	opts.StaticDir = "assets"
+	opts.OutputFile = "assets.go"
+
+	var buff bytes.Buffer
+
+	err = Generate(context.Background(), opts, &buff)
+	Equal(t, err, nil)
+	Equal(t, strings.Contains(buff.String(), "//go:embed assets/css/app.css\n"), true)
+	Equal(t, strings.Contains(buff.String(), "\treturn static.NewFromFS(&c, staticAssetsFS)\n"), true)
+	Equal(t, strings.Contains(buff.String(), "io/fs"), false)
+
+	// files outside of the output files directory can't be embedded
+	opts.OutputFile = filepath.Join("assets", "css", "assets.go")
+
+	err = Generate(context.Background(), opts, ioutil.Discard)
+	NotEqual(t, err, nil)
+
+	// nor can symlinks
+	opts.OutputFile = "assets.go"
+
+	Equal(t, os.Symlink(filepath.Join(web, "assets", "css"), filepath.Join(web, "assets", "linked")), nil)
+
+	err = Generate(context.Background(), opts, ioutil.Discard)
+	NotEqual(t, err, nil)
+	Equal(t, strings.Contains(err.Error(), "symlink"), true)
+
+	Equal(t, os.Remove(filepath.Join(web, "assets", "linked")), nil)
+
+	// nor names the go command refuses to embed
+	for name, expected := range map[string]string{
+		`a"b.txt`:  `go:embed cannot embed 'assets/a"b.txt' as 'a"b.txt' contains the character '"'`,
+		"a'b.txt":  `go:embed cannot embed 'assets/a'b.txt' as 'a'b.txt' contains the character '\''`,
+		"a:b.txt":  "go:embed cannot embed 'assets/a:b.txt' as 'a:b.txt' contains the character ':'",
+		"a|b.txt":  "go:embed cannot embed 'assets/a|b.txt' as 'a|b.txt' contains the character '|'",
+		"a<b>.txt": "go:embed cannot embed 'assets/a<b>.txt' as 'a<b>.txt' contains the character '<'",
+		"a`b.txt":  "go:embed cannot embed 'assets/a`b.txt' as 'a`b.txt' contains the character '`'",
+		"a\tb.txt": `go:embed cannot embed 'assets/a	b.txt' as 'a	b.txt' contains the character '\t'`,
+		"a☺.txt":   `go:embed cannot embed 'assets/a☺.txt' as 'a☺.txt' contains the character '☺'`,
+		"a.":       "go:embed cannot embed 'assets/a.' as 'a.' ends with a dot",
+		"nul.txt":  "go:embed cannot embed 'assets/nul.txt' as 'nul.txt' is a reserved name on Windows",
+		".git/x":   "go:embed cannot embed 'assets/.git/x' as '.git' is a version control directory",
+	} {
+
+		p := filepath.Join(web, "assets", filepath.FromSlash(name))
+
+		Equal(t, os.MkdirAll(filepath.Dir(p), 0755), nil)
+		Equal(t, ioutil.WriteFile(p, []byte(name), 0644), nil)
+
+		err = Generate(context.Background(), opts, ioutil.Discard)
+		NotEqual(t, err, nil)
+		Equal(t, err.Error(), expected)
+
+		Equal(t, os.RemoveAll(filepath.Join(web, "assets", strings.Split(name, "/")[0])), nil)
+	}
+
+	// nor files of another module, even when its go.mod is ignored
+	Equal(t, os.MkdirAll(filepath.Join(web, "assets", "sub"), 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(web, "assets", "sub", "go.mod"), []byte("module example.com/sub\n"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(web, "assets", "sub", "y.txt"), []byte("y"), 0644), nil)
+
+	opts.Ignore = `go\.mod$`
+
+	err = Generate(context.Background(), opts, ioutil.Discard)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "go:embed cannot embed 'assets/sub/y.txt' as 'assets/sub' contains a go.mod, making it a different module")
+
+	Equal(t, os.RemoveAll(filepath.Join(web, "assets", "sub")), nil)
+
+	err = Generate(context.Background(), opts, ioutil.Discard)
+	Equal(t, err, nil)
+}
+
+func TestGenerateConstants(t *testing.T) {
+
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+
+	for _, name := range []string{"css/app.css", "js/app.min.js", "app-css", "app.css", "img/logo.svg", "über.html", "2x/icon.png"} {
+		Equal(t, os.MkdirAll(filepath.Dir(filepath.Join(assets, name)), 0755), nil)
+		Equal(t, ioutil.WriteFile(filepath.Join(assets, name), []byte(name), 0644), nil)
+	}
+
+	opts := testOptions()
+	opts.StaticDir = assets
+	opts.Prefix = assets
+	opts.OutputFile = filepath.Join(dir, "assets.go")
+	opts.Constants = true
+
+	for _, embed := range []bool{false, true} {
+
+		opts.Embed = embed
+
+		var buff bytes.Buffer
+
+		err := Generate(context.Background(), opts, &buff)
+		Equal(t, err, nil)
+
+		s := buff.String()
+		Equal(t, strings.Contains(s, " -constants=true\n"), true)
+		Equal(t, strings.Contains(s, `
+// Assets file paths, for use with the *static.Files returned by newStaticAssets
+const (
+	Assets2xIconPNG  = "/2x/icon.png"
+	AssetsAppCSS     = "/app-css"
+	AssetsAppCSS2    = "/app.css"
+	AssetsCSSAppCSS  = "/css/app.css"
+	AssetsImgLogoSVG = "/img/logo.svg"
+	AssetsJSAppMinJS = "/js/app.min.js"
+	AssetsÜberHTML   = "/über.html"
+)
+
+// AssetsPaths contains the path of every Assets file, sorted
+var AssetsPaths = []string{
+	Assets2xIconPNG,
+	AssetsAppCSS,
+	AssetsAppCSS2,
+	AssetsCSSAppCSS,
+	AssetsImgLogoSVG,
+	AssetsJSAppMinJS,
+	AssetsÜberHTML,
+}
+`), true)
+
+		formatted, err := format.Source(buff.Bytes())
+		Equal(t, err, nil)
+		Equal(t, string(formatted), s)
+	}
+
+	// the constants are declared in both builds, so code using them compiles with and without the dev tag
+	opts.Embed = false
+	opts.BuildTags = true
+
+	err := GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	usage := []byte("package test\n\nvar _ = append(AssetsPaths, AssetsCSSAppCSS)\n")
+
+	for _, name := range []string{"assets.go", "assets_dev.go"} {
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		Equal(t, err, nil)
+		Equal(t, strings.Contains(string(b), "\tAssetsCSSAppCSS  = \"/css/app.css\"\n"), true)
+		Equal(t, typeCheck(b, usage), nil)
+	}
+
+	Equal(t, identifier("/static/test-files/teststart/plainfile.txt"), "StaticTestFilesTeststartPlainfileTxt")
+	Equal(t, identifier("/api/v1/user_id.json"), "APIV1UserIDJSON")
+}
+
+func TestGenerateAccessor(t *testing.T) {
+
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+
+	Equal(t, os.MkdirAll(assets, 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(assets, "app.js"), []byte("alert(1)"), 0644), nil)
+
+	opts := testOptions()
+	opts.StaticDir = assets
+	opts.Prefix = dir
+	opts.OutputFile = filepath.Join(dir, "assets.go")
+	opts.Accessor = "Assets"
+	opts.BuildTags = true
+
+	accessor := `
+// AssetsConfig is the config Assets is initialized with on first use, it may be
+// changed before then i.e. to read local files during development.
+var AssetsConfig = &static.Config{UseStaticFiles: true}
+
+// Assets returns the Assets static files, initialized with AssetsConfig on first
+// use so their cost is only paid once they are needed.
+func Assets() (*static.Files, error) {
+
+	staticAssetsOnce.Do(func() {
+		staticAssetsFiles, staticAssetsErr = newStaticAssets(AssetsConfig)
+	})
+
+	return staticAssetsFiles, staticAssetsErr
+}
+`
+
+	for _, mode := range []string{"data", "embed", "init"} {
+
+		opts.Embed = mode == "embed"
+		opts.Init = mode == "init"
+
+		err := GenerateFile(context.Background(), opts)
+		Equal(t, err, nil)
+
+		for _, name := range []string{opts.OutputFile, devFileName(opts.OutputFile)} {
+
+			b, err := ioutil.ReadFile(name)
+			Equal(t, err, nil)
+
+			s := string(b)
+			Equal(t, strings.Contains(s, " -accessor=Assets"), true)
+			Equal(t, strings.Contains(s, "\t\"sync\"\n"), true)
+			Equal(t, strings.Contains(s, accessor), true)
+
+			formatted, err := format.Source(b)
+			Equal(t, err, nil)
+			Equal(t, string(formatted), s)
+		}
+	}
+
+	for _, name := range []string{"assets", "_Assets", "Assets Files", "1Assets"} {
+
+		opts.Accessor = name
+
+		err := Generate(context.Background(), opts, ioutil.Discard)
+		NotEqual(t, err, nil)
+		Equal(t, err.Error(), "invalid Accessor Name '"+name+"', it must be an exported Go identifier")
+	}
+
+	// the path list is only declared along with the constants
+	opts.Accessor = "AssetsPaths"
+	opts.Constants = true
+
+	err := Generate(context.Background(), opts, ioutil.Discard)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "invalid Accessor Name 'AssetsPaths', it is the name of the generated path list")
+
+	opts.Constants = false
+
+	var buff bytes.Buffer
+
+	err = Generate(context.Background(), opts, &buff)
+	Equal(t, err, nil)
+	Equal(t, typeCheck(buff.Bytes()), nil)
+}
+
+func TestGenerateArg(t *testing.T) {
+
+	tests := []struct {
+		arg      string
+		expected string
+	}{
+		{arg: "-i=assets", expected: "-i=assets"},
+		{arg: `-ignore=\.js$`, expected: `-ignore=\.js$`},
+		{arg: `-ignore=(a|b$)`, expected: `-ignore=(a|b$)`},
+		{arg: `-ignore=$HOME`, expected: `-ignore=${DOLLAR}HOME`},
+		{arg: `-ignore=a${b}`, expected: `-ignore=a${DOLLAR}{b}`},
+		{arg: `-ignore=a b`, expected: `"-ignore=a b"`},
+		{arg: `-ignore=a\tb "c"`, expected: `"-ignore=a\\tb \"c\""`},
+		{arg: "-ignore=a\tb", expected: `"-ignore=a\tb"`},
+		{arg: `"quoted"`, expected: `"\"quoted\""`},
+		{arg: "-i=\xff", expected: `"-i=\xff"`},
+	}
+
+	for _, test := range tests {
+		Equal(t, generateArg(test.arg), test.expected)
+		Equal(t, splitGenerate("//go:generate "+generateArg(test.arg)), []string{test.arg})
+	}
+}
+
+// splitGenerate splits and expands a go:generate line the same way go generate
+// does, every environment variable other than DOLLAR expanding to <NAME>
+func splitGenerate(line string) []string {
+
+	var words []string
+
+	line = strings.TrimPrefix(line, "//go:generate ")
+
+	for {
+
+		line = strings.TrimLeft(line, " \t")
+		if len(line) == 0 {
+			break
+		}
+
+		if line[0] == '"' {
+
+			i := 1
+
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+
+			if i >= len(line) {
+				return append(words, "<mismatched quoted string>")
+			}
+
+			word, err := strconv.Unquote(line[:i+1])
+			if err != nil {
+				return append(words, "<bad quoted string>")
+			}
+
+			words = append(words, word)
+			line = line[i+1:]
+
+			if len(line) > 0 && line[0] != ' ' && line[0] != '\t' {
+				return append(words, "<expect space after quoted argument>")
+			}
+
+			continue
+		}
+
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			i = len(line)
+		}
+
+		words = append(words, line[:i])
+		line = line[i:]
+	}
+
+	for i, word := range words {
+		words[i] = os.Expand(word, func(name string) string {
+
+			if name == "DOLLAR" {
+				return "$"
+			}
+
+			return "<" + name + ">"
+		})
+	}
+
+	return words
+}
+
+// sourcePkgDirs are the packages of this repository the generated sources
+// import, directly or not, keyed by import path
+var sourcePkgDirs = map[string]string{
+	"github.com/go-playground/statics/static":            "../static",
+	"github.com/go-playground/statics/internal/gomod":    "../internal/gomod",
+	"github.com/go-playground/statics/internal/snapshot": "../internal/snapshot",
+}
+
+var (
+	sourcePkgsMu sync.Mutex
+	sourcePkgs   = map[string]*types.Package{}
+)
+
+// sourceImporter imports the packages of this repository type checked from
+// their sources and the standard library from export data
+type sourceImporter struct {
+	std types.Importer
+}
+
+func (i sourceImporter) Import(path string) (*types.Package, error) {
+
+	dir, ok := sourcePkgDirs[path]
+	if !ok {
+		return i.std.Import(path)
+	}
+
+	sourcePkgsMu.Lock()
+	pkg, ok := sourcePkgs[path]
+	sourcePkgsMu.Unlock()
+
+	if ok {
+		return pkg, nil
+	}
+
+	fset := token.NewFileSet()
+
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ast.File
+
+	for _, f := range pkgs[filepath.Base(dir)].Files {
+		files = append(files, f)
+	}
+
+	conf := types.Config{Importer: i}
+
+	pkg, err = conf.Check(path, fset, files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sourcePkgsMu.Lock()
+	sourcePkgs[path] = pkg
+	sourcePkgsMu.Unlock()
+
+	return pkg, nil
+}
+
+var stdImporter = importer.Default()
+
+// typeCheck parses and type checks the generated sources srcs as one package
+func typeCheck(srcs ...[]byte) error {
+
+	fset := token.NewFileSet()
+
+	files := make([]*ast.File, len(srcs))
+
+	for i, src := range srcs {
+
+		f, err := parser.ParseFile(fset, fmt.Sprintf("assets%d.go", i), src, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+
+		files[i] = f
+	}
+
+	conf := types.Config{Importer: sourceImporter{std: stdImporter}}
+
+	_, err := conf.Check("test", fset, files, nil)
+
+	return err
+}
+
+func FuzzGenerate(f *testing.F) {
+
+	f.Add("app.js", "", "assets", false)
+	f.Add(`quote".js`, `\.swp$`, "Assets", false)
+	f.Add("back`tick.js", "a b", "_assets", false)
+	f.Add("my file.css", `$HOME|"x"`, "ünicode", false)
+	f.Add("config", "\t", "files", false)
+	f.Add("\xff\xfe.bin", "${x}", "a1", false)
+	f.Add("-", "", "assets", false)
+	f.Add("paths", "", "files", false)
+	f.Add("ignored", ".", "assets", false)
+	f.Add("\x7f", "", "_", false)
+	f.Add("app.js", "", "assets", true)
+	f.Add("my file.css", "", "assets", true)
+	f.Add(`a"b.txt`, "", "assets", true)
+	f.Add("a:b.txt", "", "assets", true)
+	f.Add("nul.txt", "", "assets", true)
+	f.Add("ü.txt", "", "assets", true)
+	f.Add("a]b{c}.txt", "", "assets", true)
+	f.Add("\xff.bin", "", "assets", true)
+	f.Add("go.mod", "", "assets", true)
+
+	f.Fuzz(func(t *testing.T, name, ignore, group string, embed bool) {
+
+		if len(name) == 0 || len(name) > 200 || name == "." || name == ".." || strings.ContainsAny(name, "/\x00") {
+			t.Skip()
+		}
+
+		dir := t.TempDir()
+		assets := filepath.Join(dir, "assets")
+		output := filepath.Join(dir, "assets.go")
+
+		if err := os.MkdirAll(assets, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(assets, name), []byte(name), 0644); err != nil {
+			t.Skip()
+		}
+
+		opts := &Options{
+			StaticDir:  assets,
+			OutputFile: output,
+			Pkg:        "test",
+			Group:      group,
+			Ignore:     ignore,
+			Prefix:     assets,
+			Embed:      embed,
+			Constants:  true,
+			Accessor:   "Files",
+		}
+
+		// go:embed patterns are relative to the output files directory
+		if embed {
+			opts.Prefix = dir
+		}
+
+		var buff bytes.Buffer
+
+		err := Generate(context.Background(), opts, &buff)
+
+		re, rerr := regexp.Compile(ignore)
+
+		if rerr != nil || !token.IsIdentifier(group) {
+			if err == nil {
+				t.Fatalf("expected an error for group %q and ignore %q", group, ignore)
+			}
+			return
+		}
+
+		ignored := len(ignore) > 0 && re.MatchString(filepath.Join(assets, name))
+
+		// the generator must refuse every name go:embed refuses, and only those
+		if embed && !ignored && !embeddable(name) {
+			if err == nil {
+				t.Fatalf("expected an error embedding %q", name)
+			}
+			return
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		src := buff.Bytes()
+
+		if err = typeCheck(src); err != nil {
+			t.Fatalf("generated source doesn't compile: %s\n%s", err, src)
+		}
+
+		formatted, err := format.Source(src)
+		if err != nil || !bytes.Equal(formatted, src) {
+			t.Fatalf("generated source isn't gofmt formatted: %v\n%s", err, src)
+		}
+
+		args := []string{"statics", "-i=" + assets, "-o=" + output, "-pkg=test", "-group=" + group}
+
+		if len(ignore) > 0 {
+			args = append(args, "-ignore="+ignore)
+		}
+
+		args = append(args, "-prefix="+opts.Prefix)
+
+		if embed {
+			args = append(args, "-embed=true")
+		}
+
+		args = append(args, "-constants=true", "-accessor=Files")
+
+		line := string(src[:bytes.IndexByte(src, '\n')])
+
+		if words := splitGenerate(line); fmt.Sprint(words) != fmt.Sprint(args) || len(words) != len(args) {
+			t.Fatalf("go:generate line %s runs %q, expected %q", line, words, args)
+		}
+
+		if embed {
+
+			// parse the directives as the go command does
+			if err = ioutil.WriteFile(output, src, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			pkg, err := build.ImportDir(dir, 0)
+			if err != nil {
+				t.Fatalf("go:embed directives don't parse: %s\n%s", err, src)
+			}
+
+			expected := []string{"assets/" + name}
+
+			if ignored {
+				expected = nil
+			}
+
+			if fmt.Sprintf("%q", pkg.EmbedPatterns) != fmt.Sprintf("%q", expected) {
+				t.Fatalf("go:embed patterns are %q, expected %q\n%s", pkg.EmbedPatterns, expected, src)
+			}
+
+			return
+		}
+
+		parsed, err := parseAssets("assets.go", src)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := string(os.PathSeparator) + name
+
+		if _, found := parsed[path]; !found && !ignored {
+			t.Fatalf("generated source is missing %q", path)
+		}
+	})
+}
+
+// embeddable returns whether the go command embeds a file named name using a
+// pattern matching only it, as documented by module.CheckFilePath and embed
+func embeddable(name string) bool {
+
+	if !utf8.ValidString(name) || strings.Trim(name, ".") == "" || strings.HasSuffix(name, ".") {
+		return false
+	}
+
+	for _, r := range name {
+
+		switch {
+		case r >= utf8.RuneSelf && unicode.IsLetter(r):
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune("!#$%&()+,-.=@]^_{}~ ", r):
+		default:
+			// including the glob characters * ? [ \ which would match other files
+			return false
+		}
+	}
+
+	short := strings.SplitN(name, ".", 2)[0]
+
+	for _, reserved := range []string{"CON", "PRN", "AUX", "NUL", "COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9", "LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9"} {
+
+		if strings.EqualFold(short, reserved) {
+			return false
+		}
+	}
+
+	// a go.mod makes the assets directory another module
+	return name != ".bzr" && name != ".hg" && name != ".git" && name != ".svn" && name != "go.mod"
+}
+
+// syncBuffer is a bytes.Buffer safe for use by the watcher and test at once
+type syncBuffer struct {
+	m sync.Mutex
+	b bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.b.String()
+}
+
+func TestWatch(t *testing.T) {
+
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+
+	Equal(t, os.MkdirAll(assets, 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(assets, "app.js"), []byte("alert(1)"), 0644), nil)
+
+	var progress syncBuffer
+
+	opts := testOptions()
+	opts.StaticDir = assets
+	opts.Prefix = dir
+	opts.Ignore = `\.swp$`
+	opts.OutputFile = filepath.Join(dir, "assets.go")
+	opts.Progress = &progress
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- Watch(ctx, opts, 5*time.Millisecond, 20*time.Millisecond)
+	}()
+
+	waitFor := func(s string) bool {
+
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+
+			if strings.Contains(progress.String(), s) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	Equal(t, waitFor("Watching "+assets+" for changes\n"), true)
+
+	b, err := ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, strings.Contains(string(b), `Path:    "/assets/app.js",`), true)
+
+	// ignored files never trigger a rebuild
+	Equal(t, ioutil.WriteFile(filepath.Join(assets, ".app.js.swp"), []byte("swap"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(assets, "app.css"), []byte("body {}"), 0644), nil)
+
+	Equal(t, waitFor("Rebuilt "+opts.OutputFile), true)
+	Equal(t, strings.Count(progress.String(), "Rebuilt "), 1)
+	Equal(t, strings.Contains(progress.String(), ": 1 added, 0 removed,"), true)
+
+	b, err = ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, strings.Contains(string(b), `Path:    "/assets/app.css",`), true)
+	Equal(t, strings.Contains(string(b), ".app.js.swp"), false)
+
+	cancel()
+	Equal(t, <-done, nil)
+
+	opts.Init = true
+	NotEqual(t, Watch(context.Background(), opts, time.Millisecond, 0), nil)
+
+	opts.Init = false
+	NotEqual(t, Watch(context.Background(), opts, 0, 0), nil)
+}
+
+func TestCheck(t *testing.T) {
+
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+
+	Equal(t, os.MkdirAll(filepath.Join(assets, "css"), 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(assets, "css", "app.css"), []byte("body {}"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(assets, "app.js"), []byte("alert(1)"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(assets, "old.js"), []byte("old"), 0644), nil)
+
+	opts := testOptions()
+	opts.StaticDir = assets
+	opts.Prefix = dir
+	opts.OutputFile = filepath.Join(dir, "assets.go")
+
+	drift, err := Check(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, drift.HasDrift(), true)
+	Equal(t, drift.Added, []string{"/assets", "/assets/app.js", "/assets/css", "/assets/css/app.css", "/assets/old.js"})
+
+	err = GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	before, err := ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+
+	// only metadata changed
+	Equal(t, os.Chtimes(filepath.Join(assets, "app.js"), time.Now(), time.Now().Add(time.Hour)), nil)
+
+	drift, err = Check(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, drift.HasDrift(), false)
+
+	Equal(t, ioutil.WriteFile(filepath.Join(assets, "app.js"), []byte("alert(2)"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(assets, "css", "new.css"), []byte("p {}"), 0644), nil)
+	Equal(t, os.Remove(filepath.Join(assets, "old.js")), nil)
+
+	drift, err = Check(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, drift.HasDrift(), true)
+	Equal(t, drift.Added, []string{"/assets/css/new.css"})
+	Equal(t, drift.Removed, []string{"/assets/old.js"})
+	Equal(t, drift.Changed, []string{"/assets/app.js"})
+
+	after, err := ioutil.ReadFile(opts.OutputFile)
+	Equal(t, err, nil)
+	Equal(t, string(after), string(before))
+
+	// any other difference is the options the file was generated with
+	err = GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	drift, err = Check(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, drift.HasDrift(), false)
+
+	for _, change := range []func(o *Options){
+		func(o *Options) { o.Pkg = "other" },
+		func(o *Options) { o.Constants = true },
+		func(o *Options) { o.Accessor = "Assets" },
+	} {
+
+		changed := *opts
+		change(&changed)
+
+		drift, err = Check(context.Background(), &changed)
+		Equal(t, err, nil)
+		Equal(t, drift.HasDrift(), true)
+		Equal(t, len(drift.Added)+len(drift.Removed)+len(drift.Changed), 0)
+		Equal(t, drift.Files, []string{opts.OutputFile})
+	}
+
+	// as is the dev file
+	opts.BuildTags = true
+	opts.Constants = true
+
+	err = GenerateFile(context.Background(), opts)
+	Equal(t, err, nil)
+
+	drift, err = Check(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, drift.HasDrift(), false)
+
+	dev := filepath.Join(dir, "assets_dev.go")
+
+	b, err := ioutil.ReadFile(dev)
+	Equal(t, err, nil)
+	Equal(t, ioutil.WriteFile(dev, bytes.Replace(b, []byte("package test"), []byte("package other"), 1), 0644), nil)
+
+	drift, err = Check(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, drift.Files, []string{dev})
+
+	Equal(t, os.Remove(dev), nil)
+
+	drift, err = Check(context.Background(), opts)
+	Equal(t, err, nil)
+	Equal(t, drift.Files, []string{dev})
+
+	Equal(t, ioutil.WriteFile(opts.OutputFile, []byte("package test\n\nfunc {"), 0644), nil)
+
+	_, err = Check(context.Background(), opts)
+	NotEqual(t, err, nil)
+}
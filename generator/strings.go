@@ -0,0 +1,118 @@
+package generator
+
+// the templates are written in gofmt canonical form, as the generated source is
+// streamed and never formatted afterwards; go/format needs the whole file in
+// memory. TestGenerateFormatted and FuzzGenerate fail if the output isn't gofmt
+// clean, so keep them passing when changing a template.
+const (
+	initFile = `//go:generate statics %s
+
+package %s
+
+%s
+
+// newStatic%s initializes a new *static.Files instance for use
+func newStatic%s(config *static.Config) (*static.Files, error) {
+
+	return static.New(config, &static.DirFile{})
+}
+`
+	startFile = `//go:generate statics %s
+// %s%s
+
+package %s
+
+%s
+
+// newStatic%s initializes a new *static.Files instance for use
+func newStatic%s(config *static.Config) (*static.Files, error) {
+
+%s	c := *config
+%s
+	return static.New(&c, &static.DirFile{
+`
+	prodBuildTag = "//go:build !dev\n\n"
+	devFile      = `//go:build dev
+
+//go:generate statics %s
+
+package %s
+
+%s
+
+// newStatic%s initializes a new *static.Files instance for use, when built
+// with the dev tag files are always read from disk.
+func newStatic%s(config *static.Config) (*static.Files, error) {
+
+%s	c := *config
+	c.UseStaticFiles = false
+%s
+	return static.New(&c, &static.DirFile{})
+}
+`
+	locationCaller = "\t_, source, _, _ := runtime.Caller(0)\n\n"
+	locationAssign = `
+	if c.Location == nil {
+		c.Location = &static.Location{Module: %q, Dir: %q, Source: source}
+	}
+`
+	embedFile = `//go:generate statics %s
+// %s%s
+
+package %s
+
+%s
+
+%svar static%sFS embed.FS
+
+// newStatic%s initializes a new *static.Files instance for use
+func newStatic%s(config *static.Config) (*static.Files, error) {
+
+%s	c := *config
+%s%s}
+`
+	embedSub = `
+	fsys, err := fs.Sub(static%sFS, %q)
+	if err != nil {
+		return nil, err
+	}
+
+	return static.NewFromFS(&c, fsys)
+`
+	embedReturn = `
+	return static.NewFromFS(&c, static%sFS)
+`
+	endFile = `	})
+}
+`
+	accessorFuncs = `
+var (
+	static%[2]sOnce  sync.Once
+	static%[2]sFiles *static.Files
+	static%[2]sErr   error
+)
+
+// %[1]sConfig is the config %[1]s is initialized with on first use, it may be
+// changed before then i.e. to read local files during development.
+var %[1]sConfig = &static.Config{UseStaticFiles: true}
+
+// %[1]s returns the %[2]s static files, initialized with %[1]sConfig on first
+// use so their cost is only paid once they are needed.
+func %[1]s() (*static.Files, error) {
+
+	static%[2]sOnce.Do(func() {
+		static%[2]sFiles, static%[2]sErr = newStatic%[2]s(%[1]sConfig)
+	})
+
+	return static%[2]sFiles, static%[2]sErr
+}
+`
+
+	dirFileFields = `%sPath:    %q,
+%sName:    %q,
+%sSize:    %d,
+%sMode:    os.FileMode(%d),
+%sModTime: %v,
+%sIsDir:   %t,
+%sCompressed: ` + "`\n"
+)
@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/go-playground/statics/internal/snapshot"
+	"github.com/go-playground/statics/static"
+)
+
+// Watch generates opts.OutputFile and keeps regenerating it whenever the files
+// under opts.StaticDir change, until ctx is cancelled.
+//
+// The static directory is polled every interval, honouring opts.Ignore and
+// following symlinks just like generation does, and the output file is only
+// regenerated once no further changes have been seen for debounce. Each rebuild
+// is written atomically and summarised to opts.Progress; a failed rebuild is
+// reported and retried on the next change rather than stopping the watch.
+func Watch(ctx context.Context, opts *Options, interval, debounce time.Duration) error {
+
+	if opts.Init {
+		return errors.New("watch is not supported when only initializing the static file")
+	}
+
+	if interval <= 0 {
+		return errors.New("invalid Watch Interval")
+	}
+
+	g, err := newGenerator(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	// per file progress is too noisy when watching, only summaries are reported
+	genOpts := *opts
+	genOpts.Progress = nil
+
+	abs, err := filepath.Abs(opts.StaticDir)
+	if err != nil {
+		return err
+	}
+
+	// walked in local mode, following symlinks just as generation does
+	files, err := static.New(&static.Config{UseStaticFiles: false, AbsPkgPath: abs}, &static.DirFile{})
+	if err != nil {
+		return err
+	}
+
+	take := func() (snapshot.Snapshot, error) {
+		return g.snapshot(files)
+	}
+
+	built, err := take()
+	if err != nil {
+		return err
+	}
+
+	if err = GenerateFile(ctx, &genOpts); err != nil {
+		return err
+	}
+
+	summary(opts.Progress, "Watching %s for changes", opts.StaticDir)
+
+	snapshot.Poll(ctx, built, interval, debounce, take, func(from, to snapshot.Snapshot) bool {
+
+		added, removed, modified := from.Diff(to)
+		start := time.Now()
+
+		if err := GenerateFile(ctx, &genOpts); err != nil {
+
+			if ctx.Err() == nil {
+				summary(opts.Progress, "Rebuilding %s failed: %s", opts.OutputFile, err)
+			}
+
+			// leave the last build as is so the failed changes are retried on the next change
+			return false
+		}
+
+		summary(opts.Progress, "Rebuilt %s in %s: %d added, %d removed, %d changed", opts.OutputFile, time.Since(start).Round(time.Millisecond), added, removed, modified)
+
+		return true
+	})
+
+	return nil
+}
+
+func summary(w io.Writer, format string, a ...interface{}) {
+
+	if w != nil {
+		fmt.Fprintf(w, format+"\n", a...)
+	}
+}
+
+// snapshot fingerprints every file and directory under the static directory,
+// files being its local mode static.Files, honouring the Ignore option
+func (g *generator) snapshot(files *static.Files) (snapshot.Snapshot, error) {
+
+	return snapshot.Take(func(fn fs.WalkDirFunc) error {
+
+		return files.Walk("/", func(path string, d fs.DirEntry, err error) error {
+
+			if err == nil {
+				err = g.ctx.Err()
+			}
+
+			if err != nil || path == "/" || g.ignore == nil {
+				return fn(path, d, err)
+			}
+
+			// matched against the same path as when generating
+			if g.ignore.MatchString(g.opts.StaticDir + filepath.FromSlash(path)) {
+
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+
+				return nil
+			}
+
+			return fn(path, d, nil)
+		})
+	})
+}
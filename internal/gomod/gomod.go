@@ -0,0 +1,54 @@
+// Package gomod reads the module path from a go.mod file, shared by the static
+// runtime and the generator so both agree on which module a directory is in.
+package gomod
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ModulePath returns the module path declared in the go.mod file gomod, or an
+// empty string if it cannot be read.
+func ModulePath(gomod string) string {
+
+	f, err := os.Open(gomod)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+
+		module := line[len("module"):]
+
+		// the keyword must be followed by whitespace or a quoted path, otherwise
+		// it's some other line that happens to start with module
+		if len(module) == 0 || (module[0] != ' ' && module[0] != '\t' && module[0] != '"') {
+			continue
+		}
+
+		module = strings.TrimSpace(module)
+
+		if i := strings.Index(module, "//"); i != -1 {
+			module = strings.TrimSpace(module[:i])
+		}
+
+		if unquoted, err := strconv.Unquote(module); err == nil {
+			module = unquoted
+		}
+
+		return module
+	}
+
+	return ""
+}
@@ -0,0 +1,36 @@
+package gomod
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestModulePath(t *testing.T) {
+
+	dir := t.TempDir()
+
+	tests := []struct {
+		contents string
+		expected string
+	}{
+		{"module example.com/project\n", "example.com/project"},
+		{"// comment\nmodule \"example.com/project\" // trailing\n\ngo 1.16\n", "example.com/project"},
+		{"module\texample.com/project\n", "example.com/project"},
+		{"modulefoo example.com/other\nmodule example.com/project\n", "example.com/project"},
+		{"modulefoo example.com/other\n", ""},
+		{"go 1.16\n", ""},
+	}
+
+	for _, tt := range tests {
+
+		gomod := filepath.Join(dir, "go.mod")
+
+		Equal(t, ioutil.WriteFile(gomod, []byte(tt.contents), 0644), nil)
+		Equal(t, ModulePath(gomod), tt.expected)
+	}
+
+	Equal(t, ModulePath(filepath.Join(dir, "missing", "go.mod")), "")
+}
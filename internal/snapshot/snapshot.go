@@ -0,0 +1,138 @@
+// Package snapshot detects changes to a tree of files by polling it, shared by
+// the static package's LiveReload and the generator's Watch so both agree on
+// what counts as a change.
+package snapshot
+
+import (
+	"context"
+	"io/fs"
+	"time"
+)
+
+// Snapshot maps the path of every file and directory walked to its fingerprint
+type Snapshot map[string]fingerprint
+
+type fingerprint struct {
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// Take fingerprints every file and directory walk calls its fs.WalkDirFunc with,
+// stopping at the first error.
+func Take(walk func(fs.WalkDirFunc) error) (Snapshot, error) {
+
+	s := Snapshot{}
+
+	err := walk(func(path string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		s[path] = fingerprint{
+			size:    fi.Size(),
+			mode:    fi.Mode(),
+			modTime: fi.ModTime(),
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Equal reports whether other has the same paths and fingerprints as s
+func (s Snapshot) Equal(other Snapshot) bool {
+
+	added, removed, changed := s.Diff(other)
+
+	return added+removed+changed == 0
+}
+
+// Diff returns the number of paths added, removed and changed in other
+func (s Snapshot) Diff(other Snapshot) (added, removed, changed int) {
+
+	for path, fp := range other {
+
+		old, found := s[path]
+
+		switch {
+		case !found:
+			added++
+		case old.size != fp.size || old.mode != fp.mode || !old.modTime.Equal(fp.modTime):
+			changed++
+		}
+	}
+
+	for path := range s {
+
+		if _, found := other[path]; !found {
+			removed++
+		}
+	}
+
+	return
+}
+
+// Poll takes a snapshot every interval until ctx is cancelled and calls changed
+// once the files differ from base and have stayed the same for at least
+// debounce. changed reports whether it handled the change; if it did the new
+// snapshot becomes base, otherwise the change is retried on the next change.
+// A nil base is replaced by the first snapshot successfully taken.
+func Poll(ctx context.Context, base Snapshot, interval, debounce time.Duration, take func() (Snapshot, error), changed func(from, to Snapshot) bool) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := base
+	var changedAt time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := take()
+		if err != nil {
+			// files are most likely being modified mid walk, try again next tick
+			continue
+		}
+
+		if base == nil {
+			base, last = current, current
+			continue
+		}
+
+		if !current.Equal(last) {
+			last = current
+			changedAt = time.Now()
+			continue
+		}
+
+		if changedAt.IsZero() || time.Since(changedAt) < debounce {
+			continue
+		}
+
+		changedAt = time.Time{}
+
+		if base.Equal(last) {
+			continue
+		}
+
+		if changed(base, last) {
+			base = last
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestSnapshot(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"a.txt":     {Data: []byte("a")},
+		"dir/b.txt": {Data: []byte("b")},
+	}
+
+	take := func() (Snapshot, error) {
+		return Take(func(fn fs.WalkDirFunc) error {
+			return fs.WalkDir(fsys, ".", fn)
+		})
+	}
+
+	before, err := take()
+	Equal(t, err, nil)
+	Equal(t, len(before), 4)
+	Equal(t, before.Equal(before), true)
+
+	fsys["a.txt"] = &fstest.MapFile{Data: []byte("changed")}
+	fsys["c.txt"] = &fstest.MapFile{Data: []byte("c")}
+	delete(fsys, "dir/b.txt")
+
+	after, err := take()
+	Equal(t, err, nil)
+	Equal(t, before.Equal(after), false)
+
+	// dir only existed for b.txt, so is removed with it
+	added, removed, changed := before.Diff(after)
+	Equal(t, added, 1)
+	Equal(t, removed, 2)
+	Equal(t, changed, 1)
+
+	_, err = Take(func(fn fs.WalkDirFunc) error {
+		return fs.WalkDir(fsys, "missing", fn)
+	})
+	NotEqual(t, err, nil)
+}
+
+func TestPoll(t *testing.T) {
+
+	var m sync.Mutex
+	fsys := fstest.MapFS{"a.txt": {Data: []byte("a")}}
+
+	take := func() (Snapshot, error) {
+
+		m.Lock()
+		defer m.Unlock()
+
+		return Take(func(fn fs.WalkDirFunc) error {
+			return fs.WalkDir(fsys, ".", fn)
+		})
+	}
+
+	set := func(name, data string) {
+
+		m.Lock()
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+		m.Unlock()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan [3]int, 10)
+	handled := false
+
+	done := make(chan struct{})
+
+	// a nil base is the first snapshot taken, so nothing has changed yet
+	go func() {
+		defer close(done)
+
+		Poll(ctx, nil, time.Millisecond, 5*time.Millisecond, take, func(from, to Snapshot) bool {
+
+			added, removed, changed := from.Diff(to)
+			calls <- [3]int{added, removed, changed}
+
+			// the first change isn't handled, so is reported again with the next
+			ok := handled
+			handled = true
+
+			return ok
+		})
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	Equal(t, len(calls), 0)
+
+	set("b.txt", "b")
+	Equal(t, <-calls, [3]int{1, 0, 0})
+
+	set("a.txt", "changed")
+	Equal(t, <-calls, [3]int{1, 0, 1})
+
+	set("c.txt", "c")
+	Equal(t, <-calls, [3]int{1, 0, 0})
+
+	cancel()
+	<-done
+}
@@ -1,19 +1,18 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"compress/gzip"
-	"encoding/base64"
+	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
-	"strings"
+	"runtime"
+	"time"
+
+	"github.com/go-playground/statics/generator"
 )
 
 var (
@@ -24,55 +23,95 @@ var (
 	flagIgnore    = flag.String("ignore", "", "Regexp for files/dirs we should ignore i.e. \\.gitignore")
 	flagPrefix    = flag.String("prefix", "", "Prefix to strip from file paths")
 	flagInit      = flag.Bool("init", false, " determines if only initializing the static file without contents")
-
-	ignoreRegexp *regexp.Regexp
-	writer       *bufio.Writer
+	flagJobs      = flag.Int("j", runtime.NumCPU(), "Number of files to compress in parallel")
+	flagCheck     = flag.Bool("check", false, "Check the output file is up to date with the static directory without writing it, exits non-zero on drift")
+	flagForce     = flag.Bool("force", false, "Regenerate the output file even when the static directory and options are unchanged")
+	flagBuildTags = flag.Bool("buildtags", false, "Embed the static files behind a !dev build tag and write a disk backed <output>_dev.go file behind the dev build tag")
+	flagEmbed     = flag.Bool("embed", false, "Embed the static files using go:embed directives, the static directory must be within the output files directory")
+	flagConstants = flag.Bool("constants", false, "Emit a constant for every file path and a sorted list of all of them, so references are checked by the compiler")
+	flagAccessor  = flag.String("accessor", "", "Name of an exported accessor, i.e. Assets, returning the static files lazily initialized on first use")
+	flagWatch     = flag.Bool("watch", false, "Keep running and regenerate the output file whenever the static directory changes")
+	flagInterval  = flag.Duration("interval", 500*time.Millisecond, "How often the static directory is polled for changes when watching")
+	flagDebounce  = flag.Duration("debounce", 250*time.Millisecond, "How long the static directory must be unchanged before regenerating when watching")
 )
 
 func main() {
 	parseFlags()
 
-	os.Remove(*flagOuputFile)
+	opts := &generator.Options{
+		StaticDir:  *flagStaticDir,
+		OutputFile: *flagOuputFile,
+		Pkg:        *flagPkg,
+		Group:      *flagGroup,
+		Ignore:     *flagIgnore,
+		Prefix:     *flagPrefix,
+		Init:       *flagInit,
+		Force:      *flagForce,
+		BuildTags:  *flagBuildTags,
+		Embed:      *flagEmbed,
+		Constants:  *flagConstants,
+		Accessor:   *flagAccessor,
+		Jobs:       *flagJobs,
+		Progress:   os.Stdout,
+	}
 
-	f, err := os.Create(*flagOuputFile)
-	if err != nil {
+	if *flagCheck {
+		check(opts)
+		return
+	}
+
+	if *flagWatch {
+		watch(opts)
+		return
+	}
+
+	// output is written atomically, a failure leaves any previous output untouched
+	if err := generator.GenerateFile(context.Background(), opts); err != nil {
 		log.Panic(err)
 	}
-	defer f.Close()
+}
 
-	funcName := strings.ToUpper((*flagGroup)[0:1]) + (*flagGroup)[1:]
+func watch(opts *generator.Options) {
 
-	writer = bufio.NewWriter(f)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	ignoreFlag := ""
-	prefixFlag := ""
+	if err := generator.Watch(ctx, opts, *flagInterval, *flagDebounce); err != nil {
+		log.Panic(err)
+	}
+}
 
-	if len(*flagIgnore) > 0 {
-		ignoreFlag = "-ignore=" + *flagIgnore
+func check(opts *generator.Options) {
+
+	opts.Progress = nil
+
+	drift, err := generator.Check(context.Background(), opts)
+	if err != nil {
+		log.Panic(err)
 	}
 
-	if len(*flagPrefix) > 0 {
-		prefixFlag = "-prefix=" + *flagPrefix
+	if !drift.HasDrift() {
+		return
 	}
 
-	if *flagInit {
-		writer.WriteString(fmt.Sprintf(initStartFile, *flagStaticDir, *flagOuputFile, *flagPkg, *flagGroup, ignoreFlag, prefixFlag, *flagPkg, funcName, funcName))
-		writer.WriteString(initEndfile)
-	} else {
-		writer.WriteString(fmt.Sprintf(startFile, *flagStaticDir, *flagOuputFile, *flagPkg, *flagGroup, ignoreFlag, prefixFlag, *flagPkg, funcName, funcName))
-		processFiles(filepath.Clean(*flagStaticDir))
-		writer.WriteString(endfile)
+	for _, path := range drift.Added {
+		fmt.Println("added:  ", path)
 	}
 
-	writer.Flush()
+	for _, path := range drift.Removed {
+		fmt.Println("removed:", path)
+	}
 
-	f.Close()
+	for _, path := range drift.Changed {
+		fmt.Println("changed:", path)
+	}
 
-	// after file written run gofmt on file
-	cmd := exec.Command("gofmt", "-s", "-w", *flagOuputFile)
-	if err = cmd.Run(); err != nil {
-		log.Panic(err)
+	for _, file := range drift.Files {
+		fmt.Println("changed:", file, "options/header")
 	}
+
+	fmt.Printf("%s is out of date with %s, run go generate\n", opts.OutputFile, opts.StaticDir)
+	os.Exit(1)
 }
 
 func parseFlags() {
@@ -96,145 +135,8 @@ func parseFlags() {
 
 	if len(*flagIgnore) > 0 {
 
-		var err error
-
-		ignoreRegexp, err = regexp.Compile(*flagIgnore)
-		if err != nil {
+		if _, err := regexp.Compile(*flagIgnore); err != nil {
 			panic("**Error Compiling Regex:" + err.Error())
 		}
 	}
 }
-
-func processFiles(dir string) {
-
-	fi, err := os.Stat(dir)
-	if err != nil {
-		log.Panic(err)
-	}
-
-	dPath := applyPathOptions(dir)
-
-	writer.WriteString(fmt.Sprintf(dirFileStart, dPath, fi.Name(), fi.Size(), fi.Mode(), fi.ModTime().Unix(), true, ""))
-	processFilesRecursive(dir, "", false, "")
-	writer.WriteString(dirFileEnd)
-}
-
-// need isSymlinkDir variable as it is valid for symlinkDir to be blank
-func processFilesRecursive(path string, dir string, isSymlinkDir bool, symlinkDir string) {
-
-	var b64File string
-	var p string
-	var tmpPath string
-
-	f, err := os.Open(path)
-	if err != nil {
-		log.Panic(err)
-	}
-
-	files, err := f.Readdir(0)
-
-	for _, file := range files {
-
-		info := file
-		b64File = ""
-		p = path + string(os.PathSeparator) + file.Name()
-		fPath := p
-
-		if isSymlinkDir {
-			fPath = strings.Replace(p, dir, symlinkDir, 1)
-		}
-
-		if ignoreRegexp != nil && ignoreRegexp.MatchString(fPath) {
-			continue
-		}
-
-		if file.IsDir() {
-
-			tmpPath = applyPathOptions(fPath)
-
-			fmt.Println("Processing:", tmpPath)
-
-			// write out here
-			writer.WriteString(fmt.Sprintf(dirFileStart, tmpPath, info.Name(), info.Size(), info.Mode(), info.ModTime().Unix(), true, ""))
-			processFilesRecursive(p, p, isSymlinkDir, symlinkDir+string(os.PathSeparator)+info.Name())
-			writer.WriteString(dirFileEndArray)
-			continue
-		}
-
-		if file.Mode()&os.ModeSymlink == os.ModeSymlink {
-
-			link, err := filepath.EvalSymlinks(p)
-			if err != nil {
-				log.Panic("Error Resolving Symlink", err)
-			}
-
-			fi, err := os.Stat(link)
-			if err != nil {
-				log.Panic(err)
-			}
-
-			info = fi
-
-			if fi.IsDir() {
-
-				tmpPath = applyPathOptions(fPath)
-
-				fmt.Println("Processing:", tmpPath)
-
-				// write out here
-				writer.WriteString(fmt.Sprintf(dirFileStart, tmpPath, file.Name(), info.Size(), info.Mode(), info.ModTime().Unix(), true, ""))
-				processFilesRecursive(link, link, true, fPath)
-				writer.WriteString(dirFileEndArray)
-				continue
-			}
-		}
-
-		// if we get here it's a file
-
-		// read file
-		b, err := ioutil.ReadFile(p)
-		if err != nil {
-			log.Panic(err)
-		}
-
-		// gzip
-		var gzBuff bytes.Buffer
-		gz := gzip.NewWriter(&gzBuff)
-		defer gz.Close()
-
-		_, err = gz.Write(b)
-		if err != nil {
-			log.Panic(err)
-		}
-
-		// Flush not quaranteed to flush, must close
-		// gz.Flush()
-		gz.Close()
-
-		// turn into chunked base64 string
-		var bb bytes.Buffer
-		b64 := base64.NewEncoder(base64.StdEncoding, &bb)
-		b64.Write(gzBuff.Bytes())
-		b64.Close()
-		// b64File += "\n"
-		chunk := make([]byte, 80)
-
-		for n, _ := bb.Read(chunk); n > 0; n, _ = bb.Read(chunk) {
-			b64File += string(chunk[0:n]) + "\n"
-		}
-
-		fPath = applyPathOptions(fPath)
-
-		fmt.Println("Processing:", fPath)
-
-		// write out here
-		writer.WriteString(fmt.Sprintf(dirFileStart, fPath, file.Name(), info.Size(), info.Mode(), info.ModTime().Unix(), false, b64File))
-		writer.WriteString(dirFileEndArray)
-	}
-}
-
-func applyPathOptions(path string) string {
-	path = strings.TrimPrefix(path, *flagPrefix)
-	path = strings.TrimLeft(path, string(os.PathSeparator))
-	return string(os.PathSeparator) + path
-}
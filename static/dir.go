@@ -1,16 +1,24 @@
 package static
 
 import (
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
+// fileSystem is implemented by every source of files a Files instance can be
+// backed by, names are always slash separated and rooted at "/".
+type fileSystem interface {
+	http.FileSystem
+	stat(name string) (os.FileInfo, error)
+}
+
 // dir implements the FileSystem interface
 type dir struct {
 	useStaticFiles bool
-	fallbackToDisk bool
 	absPkgPath     string
 	files          map[string]*file
 }
@@ -18,6 +26,8 @@ type dir struct {
 // Open returns the FileSystem DIR
 func (d dir) Open(name string) (http.File, error) {
 
+	name = cleanPath(name)
+
 	if d.useStaticFiles {
 		f, found := d.files[name]
 
@@ -25,16 +35,170 @@ func (d dir) Open(name string) (http.File, error) {
 			return f.File()
 		}
 
-		if !d.fallbackToDisk {
-			return nil, os.ErrNotExist
+		return nil, os.ErrNotExist
+	}
+
+	f, err := os.Open(d.diskPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &localFile{File: f}, nil
+}
+
+// stat returns the FileInfo for name using the static index when possible so no
+// file needs to be opened.
+func (d dir) stat(name string) (os.FileInfo, error) {
+
+	name = cleanPath(name)
+
+	if d.useStaticFiles {
+		f, found := d.files[name]
+
+		if found {
+			return f, nil
 		}
+
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+
+	return os.Stat(d.diskPath(name))
+}
+
+// diskPath returns the local filesystem path for the cleaned name
+func (d dir) diskPath(name string) string {
+	return filepath.Join(d.absPkgPath, filepath.FromSlash(name))
+}
+
+// fsDir implements the FileSystem interface for an fs.FS such as an embed.FS
+type fsDir struct {
+	fsys fs.FS
+}
+
+// Open opens name within the fs.FS, listings following any symlinks
+func (d fsDir) Open(name string) (http.File, error) {
+
+	name = cleanPath(name)
+
+	f, err := http.FS(d.fsys).Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fsFile{File: f, fsys: d.fsys, name: fsName(name)}, nil
+}
+
+func (d fsDir) stat(name string) (os.FileInfo, error) {
+	return fs.Stat(d.fsys, fsName(name))
+}
+
+// fsName returns the fs.FS name for name i.e. / => . and /css/app.css => css/app.css
+func fsName(name string) string {
+
+	name = cleanPath(name)
+
+	if name == "/" {
+		return "."
+	}
+
+	return name[1:]
+}
+
+// subDir implements the FileSystem interface for a directory within another fileSystem
+type subDir struct {
+	fs   fileSystem
+	root string
+}
+
+// Open opens name relative to the root of the sub directory
+func (s subDir) Open(name string) (http.File, error) {
+	return s.fs.Open(s.root + cleanPath(name))
+}
+
+func (s subDir) stat(name string) (os.FileInfo, error) {
+	return s.fs.stat(s.root + cleanPath(name))
+}
+
+// mountDir implements the FileSystem interface for a fileSystem mounted under
+// a path prefix, the parent directories of the prefix are reported as directories
+// containing only the next directory towards the prefix.
+type mountDir struct {
+	fs     fileSystem
+	prefix string
+}
+
+// Open opens name relative to the prefix, names above the prefix opening the
+// directories leading to it
+func (m mountDir) Open(name string) (http.File, error) {
+
+	name = cleanPath(name)
+
+	if rel, ok := m.rel(name); ok {
+		return m.fs.Open(rel)
 	}
 
-	if !strings.HasPrefix(name, d.absPkgPath) {
-		name = filepath.FromSlash(d.absPkgPath + name)
-	} else {
-		name = filepath.FromSlash(name)
+	if parent := m.parent(name); parent != nil {
+		return parent.File()
 	}
 
-	return os.Open(name)
+	return nil, os.ErrNotExist
+}
+
+func (m mountDir) stat(name string) (os.FileInfo, error) {
+
+	name = cleanPath(name)
+
+	if rel, ok := m.rel(name); ok {
+		return m.fs.stat(rel)
+	}
+
+	if parent := m.parent(name); parent != nil {
+		return parent, nil
+	}
+
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+// rel returns name relative to the mount prefix, if within it
+func (m mountDir) rel(name string) (string, bool) {
+
+	switch {
+	case m.prefix == pathSep:
+		return name, true
+	case name == m.prefix:
+		return pathSep, true
+	case strings.HasPrefix(name, m.prefix+pathSep):
+		return name[len(m.prefix):], true
+	}
+
+	return "", false
+}
+
+// parent returns a directory for name if it is a parent directory of the mount prefix
+func (m mountDir) parent(name string) *file {
+
+	if name != pathSep && !strings.HasPrefix(m.prefix, name+pathSep) {
+		return nil
+	}
+
+	next := strings.SplitN(strings.TrimPrefix(m.prefix[len(name):], pathSep), pathSep, 2)[0]
+
+	return &file{
+		path:  name,
+		name:  path.Base(name),
+		mode:  os.ModeDir | 0555,
+		isDir: true,
+		files: []*file{{
+			path:  path.Join(name, next),
+			name:  next,
+			mode:  os.ModeDir | 0555,
+			isDir: true,
+		}},
+	}
+}
+
+// cleanPath returns the shortest rooted path equivalent to name, so names can
+// never reference anything above the root i.e. /../../etc/passwd becomes /etc/passwd
+func cleanPath(name string) string {
+	return path.Clean(pathSep + name)
 }
@@ -64,8 +64,36 @@ Example Usages
 
 	// other methods for direct access
 	assets.GetHTTPFile
+	assets.Stat
+	assets.Exists
 	assets.ReadFile
 	assets.ReadDir
 	assets.ReadFiles
+	assets.ReadFilesWithOptions
+	assets.Walk
+	assets.Glob
+	assets.Sub
+
+Overlays
+
+Multiple Files instances can be stacked, the first layer being the top most, any
+layer can hide a file from the layers below it using a whiteout file named .wh.<name>
+
+	// customer overrides take precedence over the embedded base theme
+	theme := static.Overlay(custom, base)
+
+	http.Handle("/theme/", http.FileServer(theme.FS()))
+
+Live Reload
+
+In local mode pages can be reloaded automatically whenever any file served by FS()
+changes, the files are polled and browsers notified using Server-Sent Events
+
+	lr := static.NewLiveReload(ctx, assets, &static.LiveReloadConfig{Path: "/_livereload"})
+
+	http.Handle("/_livereload", lr)
+
+	// injects lr.Script() into html pages, before the closing body tag
+	http.Handle("/", lr.Inject(http.FileServer(assets.FS())))
 */
 package static
@@ -4,27 +4,113 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
 	"time"
 )
 
 type httpFile struct {
 	*bytes.Reader
 	*file
+	offset int // of the next directory entry returned by Readdir
+}
+
+// localFile wraps a file read from disk so directory listings report symlinks
+// with the FileInfo of their target, the same way the generator embeds them.
+type localFile struct {
+	*os.File
+}
+
+// symlinkInfo reports the name of the symlink but the fileinfo of its target,
+// the same way the generator embeds symlinked files and directories.
+type symlinkInfo struct {
+	os.FileInfo
+	name string
+}
+
+// Name returns the name of the symlink
+func (s *symlinkInfo) Name() string {
+	return s.name
+}
+
+// Readdir reads the contents of the directory following any symlinks
+func (l *localFile) Readdir(count int) ([]os.FileInfo, error) {
+
+	files, err := l.File.Readdir(count)
+
+	return followSymlinks(files, func(name string) (os.FileInfo, error) {
+		return os.Stat(filepath.Join(l.File.Name(), name))
+	}), err
+}
+
+// ReadDir reads the contents of the directory following any symlinks, it is
+// preferred over Readdir by http.FileServer so must report symlinks the same way.
+func (l *localFile) ReadDir(count int) ([]fs.DirEntry, error) {
+
+	files, err := l.Readdir(count)
+
+	entries := make([]fs.DirEntry, len(files))
+
+	for i, fi := range files {
+		entries[i] = fs.FileInfoToDirEntry(fi)
+	}
+
+	return entries, err
+}
+
+// fsFile wraps a file opened from an fs.FS so directory listings report symlinks,
+// i.e. from os.DirFS, with the FileInfo of their target the same as local files.
+type fsFile struct {
+	http.File
+	fsys fs.FS
+	name string
+}
+
+// Readdir reads the contents of the directory following any symlinks
+func (f *fsFile) Readdir(count int) ([]os.FileInfo, error) {
+
+	files, err := f.File.Readdir(count)
+
+	return followSymlinks(files, func(name string) (os.FileInfo, error) {
+		return fs.Stat(f.fsys, path.Join(f.name, name))
+	}), err
+}
+
+// followSymlinks replaces the directory entries of symlinks in files with the
+// FileInfo of their target, returned by stat for the entries name; a broken
+// symlink is left as is.
+func followSymlinks(files []os.FileInfo, stat func(name string) (os.FileInfo, error)) []os.FileInfo {
+
+	for i, fi := range files {
+
+		if fi.Mode()&os.ModeSymlink != os.ModeSymlink {
+			continue
+		}
+
+		target, err := stat(fi.Name())
+		if err != nil {
+			continue
+		}
+
+		files[i] = &symlinkInfo{FileInfo: target, name: fi.Name()}
+	}
+
+	return files
 }
 
 // File contains the static FileInfo
 type file struct {
-	data         []byte
-	path         string
-	name         string
-	size         int64
-	mode         os.FileMode
-	modTime      int64
-	isDir        bool
-	files        []*file
-	lastDirIndex int
+	data    []byte
+	path    string
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime int64
+	isDir   bool
+	files   []*file
 }
 
 // File returns an http.File or error
@@ -32,8 +118,8 @@ func (f *file) File() (http.File, error) {
 
 	// if production read filesystem file
 	return &httpFile{
-		bytes.NewReader(f.data),
-		f,
+		Reader: bytes.NewReader(f.data),
+		file:   f,
 	}, nil
 }
 
@@ -42,44 +128,50 @@ func (f *file) Close() error {
 	return nil
 }
 
-// Readdir returns nil fileinfo and an error because the static FileSystem does not store directories
-func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+// Readdir returns the directory entries, the offset is kept on the opened httpFile
+// as the *file is shared by every concurrent Open of the same name
+func (h *httpFile) Readdir(count int) ([]os.FileInfo, error) {
 
-	if !f.IsDir() {
+	if !h.IsDir() {
 		return nil, errors.New("not a directory")
 	}
 
 	var files []os.FileInfo
 
 	if count <= 0 {
-		files = make([]os.FileInfo, len(f.files))
-		count = len(f.files)
-		f.lastDirIndex = 0
+		files = make([]os.FileInfo, len(h.files))
+		count = len(h.files)
+		h.offset = 0
+
+		// an empty directory is not an error when reading all entries
+		if count == 0 {
+			return files, nil
+		}
 	} else {
 		files = make([]os.FileInfo, count)
-		count += f.lastDirIndex
+		count += h.offset
 	}
 
-	if f.lastDirIndex >= len(f.files) {
-		f.lastDirIndex = 0
+	if h.offset >= len(h.files) {
+		h.offset = 0
 		return nil, io.EOF
 	}
 
-	if count+f.lastDirIndex >= len(f.files) {
-		count = len(f.files)
+	if count+h.offset >= len(h.files) {
+		count = len(h.files)
 	}
 
-	i := f.lastDirIndex
+	i := h.offset
 
 	var j int
 
-	for i = f.lastDirIndex; i < count; i++ {
-		files[j] = f.files[i]
+	for i = h.offset; i < count; i++ {
+		files[j] = h.files[i]
 		j++
 	}
 
 	if count > 0 {
-		f.lastDirIndex += j
+		h.offset += j
 	}
 
 	return files[:j], nil
@@ -0,0 +1,242 @@
+package static
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/statics/internal/snapshot"
+)
+
+const (
+	defaultReloadInterval = 500 * time.Millisecond
+)
+
+// LiveReloadConfig contains the live reload configuration
+type LiveReloadConfig struct {
+	Path     string        // path the LiveReload handler is served at, used by the injected script
+	Interval time.Duration // how often the files are polled for changes, defaults to 500ms
+}
+
+// LiveReload is a development helper that polls all files served by Files.FS()
+// and notifies connected browsers, via Server-Sent Events, to reload the page
+// whenever any of them change. It is only useful in local mode, static files
+// never change.
+type LiveReload struct {
+	ctx      context.Context
+	files    *Files
+	path     string
+	interval time.Duration
+	m        sync.Mutex
+	clients  map[chan struct{}]struct{}
+}
+
+// NewLiveReload returns a new LiveReload instance polling files, and serving
+// connected browsers, until ctx is cancelled. The returned LiveReload is an
+// http.Handler serving the Server-Sent Events endpoint and should be mounted at
+// config.Path.
+func NewLiveReload(ctx context.Context, files *Files, config *LiveReloadConfig) *LiveReload {
+
+	lr := &LiveReload{
+		ctx:      ctx,
+		files:    files,
+		path:     config.Path,
+		interval: config.Interval,
+		clients:  map[chan struct{}]struct{}{},
+	}
+
+	if lr.interval <= 0 {
+		lr.interval = defaultReloadInterval
+	}
+
+	go lr.poll(ctx)
+
+	return lr
+}
+
+func (lr *LiveReload) poll(ctx context.Context) {
+
+	base, _ := lr.snapshot()
+
+	snapshot.Poll(ctx, base, lr.interval, 0, lr.snapshot, func(from, to snapshot.Snapshot) bool {
+		lr.notify()
+		return true
+	})
+}
+
+// snapshot fingerprints every file and directory served by the files
+func (lr *LiveReload) snapshot() (snapshot.Snapshot, error) {
+
+	return snapshot.Take(func(fn fs.WalkDirFunc) error {
+		return lr.files.Walk("/", fn)
+	})
+}
+
+// notify tells every connected client to reload
+func (lr *LiveReload) notify() {
+
+	lr.m.Lock()
+	defer lr.m.Unlock()
+
+	for client := range lr.clients {
+
+		select {
+		case client <- struct{}{}:
+		default:
+			// a reload is already pending for this client
+		}
+	}
+}
+
+// ServeHTTP serves the Server-Sent Events endpoint, sending a "reload" event
+// each time the files change, until the client disconnects or the LiveReload
+// context is cancelled so http.Server.Shutdown isn't held up.
+func (lr *LiveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan struct{}, 1)
+
+	lr.m.Lock()
+	lr.clients[client] = struct{}{}
+	lr.m.Unlock()
+
+	defer func() {
+		lr.m.Lock()
+		delete(lr.clients, client)
+		lr.m.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-lr.ctx.Done():
+			return
+		case <-client:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// Script returns the html script element which connects to the LiveReload
+// endpoint and reloads the page when the files change.
+func (lr *LiveReload) Script() string {
+
+	// json encoding escapes <, > and & so the path is safe within the script element
+	path, _ := json.Marshal(lr.path)
+
+	return `<script>(function(){var s=new EventSource(` + string(path) + `);s.addEventListener("reload",function(){s.close();location.reload();});})();</script>`
+}
+
+// Inject returns an http.Handler which injects the LiveReload Script into every
+// successful, unencoded html response to a GET served by next, before the
+// closing body tag. Only those are buffered, any other response is passed
+// straight through.
+func (lr *LiveReload) Inject(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		rw := &injectWriter{ResponseWriter: w, status: http.StatusOK, head: r.Method == http.MethodHead}
+
+		next.ServeHTTP(rw, r)
+
+		if !rw.decided {
+			rw.decide()
+		}
+
+		if !rw.inject {
+			return
+		}
+
+		body := rw.buff.Bytes()
+		script := []byte(lr.Script())
+
+		if i := bytes.LastIndex(body, []byte("</body>")); i != -1 {
+			body = append(body[:i:i], append(script, body[i:]...)...)
+		} else {
+			body = append(body, script...)
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(rw.status)
+		w.Write(body)
+	})
+}
+
+// injectWriter buffers successful html responses so the LiveReload script can
+// be injected, deciding on the first WriteHeader or Write once the status and
+// Content-Type are known, and passes any other response straight through.
+type injectWriter struct {
+	http.ResponseWriter
+	buff    bytes.Buffer
+	status  int
+	head    bool
+	decided bool
+	inject  bool
+}
+
+func (w *injectWriter) WriteHeader(status int) {
+
+	if w.decided {
+		return
+	}
+
+	w.status = status
+
+	// the Content-Type may still be detected from the first Write
+	if status != http.StatusOK || len(w.Header().Get("Content-Type")) > 0 {
+		w.decide()
+	}
+}
+
+func (w *injectWriter) Write(b []byte) (int, error) {
+
+	if !w.decided {
+
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+
+		w.decide()
+	}
+
+	if w.inject {
+		return w.buff.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// decide determines whether the response is buffered, writing the header of
+// those which aren't. HEAD responses have no body to inject into and an encoded
+// body can't be searched for the closing body tag, so neither are buffered.
+func (w *injectWriter) decide() {
+
+	w.decided = true
+	w.inject = w.status == http.StatusOK && !w.head && w.Header().Get("Content-Encoding") == "" &&
+		strings.HasPrefix(w.Header().Get("Content-Type"), "text/html")
+
+	if !w.inject {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
@@ -0,0 +1,78 @@
+package static
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-playground/statics/internal/gomod"
+)
+
+// EnvModuleDir is the environment variable which, when set, overrides the root
+// directory of the module used to resolve a Location i.e. when running a binary
+// on a machine where the module was not built from.
+const EnvModuleDir = "STATICS_MODULE_DIR"
+
+// Location records where the static files were generated from, it is set by the
+// generated constructor and used to resolve AbsPkgPath when it is left empty.
+type Location struct {
+	Module string // module path from go.mod i.e. github.com/username/project
+	Dir    string // slash separated directory, relative to the module root, file paths are relative to
+	Source string // the generated source files path when compiled, from runtime.Caller
+}
+
+// AbsPkgPath resolves the absolute path the static files are read from in local
+// mode. The module root is found, in order, from the EnvModuleDir environment
+// variable, the go.mod of Module in the current working directory or any of its
+// parents and finally the go.mod of Module in the directory of Source or any of
+// its parents.
+func (l *Location) AbsPkgPath() (string, error) {
+
+	if root := os.Getenv(EnvModuleDir); len(root) > 0 {
+
+		if !filepath.IsAbs(root) {
+			return "", fmt.Errorf("%s '%s' must be an absolute path", EnvModuleDir, root)
+		}
+
+		return filepath.Join(root, filepath.FromSlash(l.Dir)), nil
+	}
+
+	if wd, err := os.Getwd(); err == nil {
+
+		if root, ok := findModuleRoot(wd, l.Module); ok {
+			return filepath.Join(root, filepath.FromSlash(l.Dir)), nil
+		}
+	}
+
+	if len(l.Source) > 0 && filepath.IsAbs(l.Source) {
+
+		if root, ok := findModuleRoot(filepath.Dir(l.Source), l.Module); ok {
+			return filepath.Join(root, filepath.FromSlash(l.Dir)), nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to find the root of module '%s' from the working directory or '%s', set AbsPkgPath or the %s environment variable", l.Module, l.Source, EnvModuleDir)
+}
+
+// findModuleRoot returns the first directory, starting at dir and walking up,
+// containing a go.mod declaring module
+func findModuleRoot(dir, module string) (string, bool) {
+
+	if len(module) == 0 {
+		return "", false
+	}
+
+	for {
+
+		if gomod.ModulePath(filepath.Join(dir, "go.mod")) == module {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+
+		dir = parent
+	}
+}
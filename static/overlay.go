@@ -0,0 +1,240 @@
+package static
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// WhiteoutPrefix is the name prefix of whiteout files, an overlay layer
+	// containing a file named .wh.<name> hides <name> in all layers below it.
+	WhiteoutPrefix = ".wh."
+)
+
+// overlayDir implements the FileSystem interface for a stack of fileSystems
+type overlayDir struct {
+	layers    []fileSystem
+	whiteouts bool // whether whiteout files hide files in the layers below
+}
+
+// Overlay returns a new Files instance which stacks the given layers, the first
+// layer being the top most. Files are resolved top-down, the first layer
+// containing a file wins, and directory listings are the merged entries of all
+// layers; any layer can hide files in the layers below it using whiteout files
+// i.e. adding /css/.wh.theme.css hides /css/theme.css from the lower layers.
+func Overlay(layers ...*Files) *Files {
+
+	o := overlayDir{layers: make([]fileSystem, 0, len(layers)), whiteouts: true}
+
+	for _, layer := range layers {
+
+		if layer == nil {
+			continue
+		}
+
+		o.layers = append(o.layers, layer.fs)
+	}
+
+	return &Files{fs: o}
+}
+
+// Open opens name from the top most layer containing it, directories listing
+// the merged entries of every layer
+func (o overlayDir) Open(name string) (http.File, error) {
+
+	name = cleanPath(name)
+
+	layers, fi, err := o.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := layers[0].Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return f, nil
+	}
+
+	return &overlayFile{File: f, name: name, layers: layers, whiteouts: o.whiteouts}, nil
+}
+
+func (o overlayDir) stat(name string) (os.FileInfo, error) {
+
+	_, fi, err := o.resolve(cleanPath(name))
+
+	return fi, err
+}
+
+// resolve returns the layers, top-down, that contribute to name along with the
+// FileInfo of the top most one; any layers after the first are lower layers
+// containing a directory of the same name to be merged with it. An error other
+// than not existing, i.e. a parent being a file, is returned as is unless a layer
+// above already contains name.
+func (o overlayDir) resolve(name string) ([]fileSystem, os.FileInfo, error) {
+
+	notFound := &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+
+	if o.whiteouts && strings.HasPrefix(path.Base(name), WhiteoutPrefix) {
+		return nil, nil, notFound
+	}
+
+	var layers []fileSystem
+	var top os.FileInfo
+
+	for _, layer := range o.layers {
+
+		fi, err := layer.stat(name)
+		if err != nil && top == nil && !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+
+		if err == nil {
+
+			if top == nil {
+				top = fi
+				layers = append(layers, layer)
+
+				if !fi.IsDir() {
+					break
+				}
+
+			} else if fi.IsDir() {
+				layers = append(layers, layer)
+			}
+		}
+
+		if o.whiteouts && hidden(layer, name) {
+			break
+		}
+	}
+
+	if top == nil {
+		return nil, nil, notFound
+	}
+
+	return layers, top, nil
+}
+
+// hidden returns true if name, or any of its parent directories, has a whiteout
+// file in layer.
+func hidden(layer fileSystem, name string) bool {
+
+	for name != pathSep {
+
+		if _, err := layer.stat(path.Join(path.Dir(name), WhiteoutPrefix+path.Base(name))); err == nil {
+			return true
+		}
+
+		name = path.Dir(name)
+	}
+
+	return false
+}
+
+// overlayFile is a directory whose listing is merged from multiple layers
+type overlayFile struct {
+	http.File
+	name      string
+	layers    []fileSystem
+	whiteouts bool
+	files     []os.FileInfo
+	merged    bool
+	offset    int
+}
+
+// Readdir returns the merged directory entries of all layers, the top most
+// layer wins when the same name exists in multiple layers.
+func (o *overlayFile) Readdir(count int) ([]os.FileInfo, error) {
+
+	if !o.merged {
+
+		files, err := o.merge()
+		if err != nil {
+			return nil, err
+		}
+
+		o.files = files
+		o.merged = true
+	}
+
+	if count <= 0 {
+		files := o.files[o.offset:]
+		o.offset = len(o.files)
+		return files, nil
+	}
+
+	if o.offset >= len(o.files) {
+		return nil, io.EOF
+	}
+
+	end := o.offset + count
+
+	if end > len(o.files) {
+		end = len(o.files)
+	}
+
+	files := o.files[o.offset:end]
+	o.offset = end
+
+	return files, nil
+}
+
+func (o *overlayFile) merge() ([]os.FileInfo, error) {
+
+	var results []os.FileInfo
+
+	seen := map[string]bool{}
+
+	for _, layer := range o.layers {
+
+		files, err := readDir(layer, o.name)
+		if err != nil {
+			return nil, err
+		}
+
+		var whiteouts []string
+
+		for _, fi := range files {
+
+			if o.whiteouts && strings.HasPrefix(fi.Name(), WhiteoutPrefix) {
+				whiteouts = append(whiteouts, strings.TrimPrefix(fi.Name(), WhiteoutPrefix))
+				continue
+			}
+
+			if seen[fi.Name()] {
+				continue
+			}
+
+			seen[fi.Name()] = true
+			results = append(results, fi)
+		}
+
+		// whiteouts only hide entries of the layers below
+		for _, name := range whiteouts {
+			seen[name] = true
+		}
+	}
+
+	sort.Sort(byName(results))
+
+	return results, nil
+}
+
+// readDir returns all directory entries of name within fs
+func readDir(fs fileSystem, name string) ([]os.FileInfo, error) {
+
+	f, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Readdir(-1)
+}
@@ -5,10 +5,13 @@ import (
 	"compress/gzip"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -32,17 +35,38 @@ type DirFile struct {
 
 // Files contains a full instance of a static file collection
 type Files struct {
-	dir dir
+	fs fileSystem
+}
+
+// Precedence determines which file wins when the same name exists both in the
+// static assets and on disk
+type Precedence uint8
+
+// Precedence values
+const (
+	PreferStatic Precedence = iota // static assets win, the default
+	PreferDisk                     // files on disk win
+)
+
+// FallbackDir is a directory on disk searched when a file is not found in the static assets
+type FallbackDir struct {
+	Dir    string // the Absolute path of the directory, environment variables are expanded
+	Prefix string // optional path the directory is mounted under i.e. /uploads, defaults to the root
 }
 
 // Config contains information about how extracting the data should behave
 // NOTE: FallbackToDisk falls back to disk when file not found in static assets
 // usefull when you have a mixture of static assets and some that need to remain
-// on disk i.e. a users avatar image
+// on disk i.e. a users avatar image; directory listings are then the merged
+// entries of both the static assets and disk. FallbackDirs, when set, are searched
+// in order instead of AbsPkgPath, in both static and local mode.
 type Config struct {
-	UseStaticFiles bool
-	FallbackToDisk bool   // falls back to disk when file not found in static assets
-	AbsPkgPath     string // the Absolute package path used for local file reading when UseStaticFiles is false
+	UseStaticFiles     bool
+	FallbackToDisk     bool          // falls back to disk when file not found in static assets
+	FallbackDirs       []FallbackDir // directories searched, in order, when file not found; setting enables falling back to disk
+	FallbackPrecedence Precedence    // which of static assets or disk wins on name clashes when falling back to disk
+	AbsPkgPath         string        // the Absolute package path used for local file reading when UseStaticFiles is false
+	Location           *Location     // where the files were generated from, set by the generated constructor; resolves an empty AbsPkgPath
 }
 
 // New create a new static file instance.
@@ -51,8 +75,128 @@ func New(config *Config, dirFile *DirFile) (*Files, error) {
 	files := map[string]*file{}
 
 	if config.UseStaticFiles {
-		processFiles(files, dirFile)
-	} else {
+		processParents(files, processFiles(files, dirFile))
+	}
+
+	return newFiles(config, dir{useStaticFiles: true, files: files})
+}
+
+// NewFromFS creates a new static file instance whose static files are read from
+// fsys, such as an embed.FS, zip.Reader or os.DirFS, instead of generated DirFile
+// data; the config behaves exactly as with New, local files being read from
+// AbsPkgPath when UseStaticFiles is false. A nil config uses the static files.
+func NewFromFS(config *Config, fsys fs.FS) (*Files, error) {
+
+	if config == nil {
+		config = &Config{UseStaticFiles: true}
+	}
+
+	if config.UseStaticFiles && fsys == nil {
+		return nil, errors.New("fs.FS is required when using static files")
+	}
+
+	return newFiles(config, fsDir{fsys: fsys})
+}
+
+// NewFromMap creates a new static file instance whose static files are the
+// contents of files keyed by their slash separated path, parent directories
+// being created as needed; useful for faking static files in tests. A nil
+// config uses the static files.
+func NewFromMap(config *Config, files map[string][]byte) (*Files, error) {
+
+	if config == nil {
+		config = &Config{UseStaticFiles: true}
+	}
+
+	index := map[string]*file{
+		"/": {path: "/", mode: os.ModeDir | 0555, isDir: true},
+	}
+
+	names := make([]string, 0, len(files))
+
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		cleaned := cleanPath(name)
+
+		if f, found := index[cleaned]; found {
+
+			if f.isDir {
+				return nil, fmt.Errorf("'%s' is both a file and a directory", name)
+			}
+
+			// i.e. "a.txt" and "/a.txt", the last sorted name wins
+			f.data = files[name]
+			f.size = int64(len(f.data))
+
+			continue
+		}
+
+		f := &file{
+			path: cleaned,
+			name: path.Base(cleaned),
+			data: files[name],
+			size: int64(len(files[name])),
+			mode: 0444,
+		}
+
+		index[cleaned] = f
+
+		for child := f; ; {
+
+			dirname := path.Dir(child.path)
+
+			parent, found := index[dirname]
+			if found && !parent.isDir {
+				return nil, fmt.Errorf("'%s' is both a file and a directory", dirname)
+			}
+
+			if !found {
+				parent = &file{path: dirname, name: path.Base(dirname), mode: os.ModeDir | 0555, isDir: true}
+				index[dirname] = parent
+			}
+
+			parent.files = append(parent.files, child)
+
+			if found {
+				break
+			}
+
+			child = parent
+		}
+	}
+
+	for _, f := range index {
+		sort.Slice(f.files, func(i, j int) bool { return f.files[i].name < f.files[j].name })
+	}
+
+	return newFiles(config, dir{useStaticFiles: true, files: index})
+}
+
+// newFiles creates a new static file instance for config, static serving the
+// static files when UseStaticFiles is true.
+func newFiles(config *Config, static fileSystem) (*Files, error) {
+
+	if len(config.AbsPkgPath) == 0 && config.Location != nil && (!config.UseStaticFiles || config.FallbackToDisk) {
+
+		// a deployed binary has no go.mod to resolve from, static mode then falls
+		// back to disk relative to the working directory as it always has
+		abs, err := config.Location.AbsPkgPath()
+		if err == nil {
+			config.AbsPkgPath = abs
+		} else if !config.UseStaticFiles {
+			return nil, err
+		}
+	}
+
+	d := static
+
+	if !config.UseStaticFiles {
 
 		if strings.Contains(config.AbsPkgPath, "$") {
 			config.AbsPkgPath = os.ExpandEnv(config.AbsPkgPath)
@@ -61,16 +205,80 @@ func New(config *Config, dirFile *DirFile) (*Files, error) {
 		if !filepath.IsAbs(config.AbsPkgPath) {
 			return nil, errors.New("AbsPkgPath is required when not using static files otherwise the static package has no idea where to grab local files from when your package is used from within another package.")
 		}
+
+		d = dir{absPkgPath: filepath.Clean(config.AbsPkgPath)}
+	}
+
+	fallbacks, err := fallbackDirs(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fallbacks) == 0 {
+		return &Files{fs: d}, nil
+	}
+
+	// whiteouts are an Overlay feature, files on disk must never hide static ones
+	if config.FallbackPrecedence == PreferDisk {
+		return &Files{fs: overlayDir{layers: append(fallbacks, d)}}, nil
+	}
+
+	return &Files{fs: overlayDir{layers: append([]fileSystem{d}, fallbacks...)}}, nil
+}
+
+// fallbackDirs returns the fileSystems to fall back to when a file is not found
+func fallbackDirs(config *Config) ([]fileSystem, error) {
+
+	if len(config.FallbackDirs) == 0 {
+
+		if config.UseStaticFiles && config.FallbackToDisk {
+			return []fileSystem{dir{absPkgPath: filepath.Clean(config.AbsPkgPath)}}, nil
+		}
+
+		return nil, nil
+	}
+
+	fallbacks := make([]fileSystem, 0, len(config.FallbackDirs))
+
+	for i, fd := range config.FallbackDirs {
+
+		if strings.Contains(fd.Dir, "$") {
+			fd.Dir = os.ExpandEnv(fd.Dir)
+		}
+
+		if !filepath.IsAbs(fd.Dir) {
+			return nil, fmt.Errorf("FallbackDirs[%d].Dir '%s' must be an absolute path", i, fd.Dir)
+		}
+
+		fallbacks = append(fallbacks, mountDir{
+			fs:     dir{absPkgPath: filepath.Clean(fd.Dir)},
+			prefix: cleanPath(fd.Prefix),
+		})
 	}
 
-	return &Files{
-		dir: dir{
-			useStaticFiles: config.UseStaticFiles,
-			fallbackToDisk: config.FallbackToDisk,
-			files:          files,
-			absPkgPath:     filepath.Clean(config.AbsPkgPath),
-		},
-	}, nil
+	return fallbacks, nil
+}
+
+// processParents creates the directories above root up to "/", the same as
+// on disk, so static files can be listed and walked from "/" as local ones can
+func processParents(files map[string]*file, root *file) {
+
+	name := filepath.ToSlash(root.path)
+
+	for child := root; strings.HasPrefix(name, pathSep) && name != pathSep; {
+
+		dirname := path.Dir(name)
+
+		parent := &file{path: dirname, mode: os.ModeDir | 0555, isDir: true, files: []*file{child}}
+
+		if dirname != pathSep {
+			parent.name = path.Base(dirname)
+		}
+
+		files[dirname] = parent
+
+		child, name = parent, dirname
+	}
 }
 
 func processFiles(files map[string]*file, dirFile *DirFile) *file {
@@ -113,30 +321,56 @@ func processFiles(files map[string]*file, dirFile *DirFile) *file {
 
 // FS returns an http.FileSystem object for serving files over http
 func (f *Files) FS() http.FileSystem {
-	return f.dir
+	return f.fs
 }
 
-func (f *Files) determinePath(name string) string {
+// Sub returns a new Files instance rooted at the directory dirname, sharing the
+// underlying static or local files i.e. Sub("/templates/email") then ReadFile("/welcome.tmpl")
+func (f *Files) Sub(dirname string) (*Files, error) {
+
+	fi, err := f.Stat(dirname)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return nil, &os.PathError{Op: "sub", Path: dirname, Err: errors.New("not a directory")}
+	}
 
-	if f.dir.useStaticFiles {
-		return name
+	dirname = cleanPath(dirname)
+
+	if sub, ok := f.fs.(subDir); ok {
+		return &Files{fs: subDir{fs: sub.fs, root: cleanPath(sub.root + dirname)}}, nil
 	}
 
-	return f.dir.absPkgPath + name
+	return &Files{fs: subDir{fs: f.fs, root: dirname}}, nil
 }
 
 // GetHTTPFile returns an http.File object
 func (f *Files) GetHTTPFile(filename string) (http.File, error) {
-	return f.dir.Open(f.determinePath(filename))
+	return f.fs.Open(filename)
+}
+
+// Stat returns the FileInfo for the named file or directory from the filesystem, static or local
+// without opening it, symlinks are followed.
+func (f *Files) Stat(name string) (os.FileInfo, error) {
+	return f.fs.stat(name)
+}
+
+// Exists returns true if the named file or directory exists in the filesystem, static or local
+func (f *Files) Exists(name string) bool {
+	_, err := f.Stat(name)
+	return err == nil
 }
 
 // ReadFile returns a files contents as []byte from the filesystem, static or local
 func (f *Files) ReadFile(filename string) ([]byte, error) {
 
-	file, err := f.dir.Open(f.determinePath(filename))
+	file, err := f.fs.Open(filename)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
 	return ioutil.ReadAll(file)
 }
@@ -145,10 +379,11 @@ func (f *Files) ReadFile(filename string) ([]byte, error) {
 // a list of sorted directory entries.
 func (f *Files) ReadDir(dirname string) ([]os.FileInfo, error) {
 
-	file, err := f.dir.Open(f.determinePath(dirname))
+	file, err := f.fs.Open(dirname)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
 	results, err := file.Readdir(-1)
 	if err != nil {
@@ -160,93 +395,82 @@ func (f *Files) ReadDir(dirname string) ([]os.FileInfo, error) {
 	return results, nil
 }
 
+// ReadFilesOptions contains information about how ReadFilesWithOptions should behave
+// NOTE: Glob uses the same syntax as Files.Glob, including "**", and is matched against
+// the slash separated path relative to the requested directory, regardless of the key
+// format, so "css/*.css" selects the same files whether or not RelativeKeys is set.
+type ReadFilesOptions struct {
+	Recursive    bool   // descend into sub directories
+	RelativeKeys bool   // keys relative to the requested directory i.e. css/app.css instead of /assets/css/app.css
+	Glob         string // optional pattern a file must match to be included
+}
+
 // ReadFiles returns a directories file contents as a map[string][]byte from the filesystem, static or local
+// keys are the slash separated paths relative to the root i.e. /assets/css/app.css
 func (f *Files) ReadFiles(dirname string, recursive bool) (map[string][]byte, error) {
+	return f.ReadFilesWithOptions(dirname, &ReadFilesOptions{Recursive: recursive})
+}
 
-	dirname = f.determinePath(dirname)
+// ReadFilesWithOptions returns a directories file contents as a map[string][]byte from the filesystem, static or local
+// the keys are identical in both modes and symlinked directories are followed in both modes.
+func (f *Files) ReadFilesWithOptions(dirname string, opts *ReadFilesOptions) (map[string][]byte, error) {
 
-	results := map[string][]byte{}
-
-	file, err := f.dir.Open(dirname)
-	if err != nil {
-		return nil, err
-	}
-
-	if err = f.readFilesRecursive(dirname+pathSep, file, results, recursive); err != nil {
-		return nil, err
+	if opts == nil {
+		opts = new(ReadFilesOptions)
 	}
 
-	return results, nil
-}
-
-func (f *Files) readFilesRecursive(dirname string, file http.File, results map[string][]byte, recursive bool) error {
-
-	files, err := file.Readdir(-1)
-	if err != nil {
-		return err
+	if len(opts.Glob) > 0 {
+		if err := validGlob(opts.Glob); err != nil {
+			return nil, err
+		}
 	}
 
-	var fpath string
+	// walked paths are clean, so keys only match regardless of the input when it is too
+	dirname = cleanPath(dirname)
 
-	for _, fi := range files {
+	results := map[string][]byte{}
 
-		fpath = dirname + fi.Name()
+	err := f.Walk(dirname, func(p string, d fs.DirEntry, err error) error {
 
-		newFile, err := f.dir.Open(fpath)
 		if err != nil {
 			return err
 		}
 
-		if fi.IsDir() {
-
-			if !recursive {
-				continue
-			}
+		if d.IsDir() {
 
-			err := f.readFilesRecursive(fpath+pathSep, newFile, results, recursive)
-			if err != nil {
-				return err
+			if p != dirname && !opts.Recursive {
+				return fs.SkipDir
 			}
 
-			continue
+			return nil
 		}
 
-		if fi.Mode()&os.ModeSymlink == os.ModeSymlink {
-
-			link, err := filepath.EvalSymlinks(fpath)
-			if err != nil {
-				log.Panic("Error Resolving Symlink", err)
-			}
-
-			fi, err := os.Stat(link)
-			if err != nil {
-				log.Panic(err)
-			}
-
-			if fi.IsDir() {
-
-				if !recursive {
-					continue
-				}
-
-				err := f.readFilesRecursive(fpath+pathSep, newFile, results, recursive)
-				if err != nil {
-					return err
-				}
-
-				continue
-			}
+		if p == dirname {
+			return &os.PathError{Op: "readdir", Path: dirname, Err: errors.New("not a directory")}
 		}
 
-		if !f.dir.useStaticFiles {
-			fpath = strings.Replace(fpath, f.dir.absPkgPath, "", 1)
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, dirname), pathSep)
+
+		if len(opts.Glob) > 0 && !matchGlob(opts.Glob, rel) {
+			return nil
 		}
 
-		results[fpath], err = ioutil.ReadAll(newFile)
+		b, err := f.ReadFile(p)
 		if err != nil {
 			return err
 		}
+
+		if opts.RelativeKeys {
+			p = rel
+		}
+
+		results[p] = b
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return results, nil
 }
@@ -1,11 +1,22 @@
 package static
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	. "gopkg.in/go-playground/assert.v1"
@@ -288,6 +299,49 @@ func TestStaticNew(t *testing.T) {
 	defer resp.Body.Close()
 }
 
+func TestStaticReaddirConcurrent(t *testing.T) {
+
+	staticFiles, err := New(&Config{UseStaticFiles: true}, testDirFile)
+	Equal(t, err, nil)
+
+	// every opened file has its own offset, the same directory opened twice
+	// lists independently
+	f1, err := staticFiles.GetHTTPFile("/static/test-files/teststart")
+	Equal(t, err, nil)
+
+	f2, err := staticFiles.GetHTTPFile("/static/test-files/teststart")
+	Equal(t, err, nil)
+
+	fis, err := f1.Readdir(2)
+	Equal(t, err, nil)
+	Equal(t, len(fis), 2)
+
+	fis, err = f2.Readdir(2)
+	Equal(t, err, nil)
+	Equal(t, len(fis), 2)
+
+	fis, err = f1.Readdir(2)
+	Equal(t, err, nil)
+	Equal(t, len(fis), 1)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				_ = staticFiles.Walk("/", func(string, fs.DirEntry, error) error { return nil })
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
 func TestLocalNew(t *testing.T) {
 
 	config := &Config{
@@ -406,6 +460,35 @@ func TestLocalNew(t *testing.T) {
 	defer resp.Body.Close()
 }
 
+func TestFileServerListing(t *testing.T) {
+
+	configs := []*Config{
+		{
+			UseStaticFiles: true,
+		},
+		{
+			UseStaticFiles: false,
+			AbsPkgPath:     getGOPATH() + "/src/github.com/go-playground/statics",
+		},
+	}
+
+	for _, config := range configs {
+
+		staticFiles, err := New(config, testDirFile)
+		Equal(t, err, nil)
+
+		w := httptest.NewRecorder()
+		http.FileServer(staticFiles.FS()).ServeHTTP(w, httptest.NewRequest("GET", "/static/test-files/teststart/", nil))
+		Equal(t, w.Code, http.StatusOK)
+
+		// symlinked directories are listed as directories in both modes
+		body := w.Body.String()
+		Equal(t, strings.Contains(body, `<a href="plainfile.txt">plainfile.txt</a>`), true)
+		Equal(t, strings.Contains(body, `<a href="symlinkeddir/">symlinkeddir/</a>`), true)
+		Equal(t, strings.Contains(body, `<a href="symlinkedfile.txt">symlinkedfile.txt</a>`), true)
+	}
+}
+
 func TestBadLocalAbsPath(t *testing.T) {
 
 	config := &Config{
@@ -418,3 +501,1147 @@ func TestBadLocalAbsPath(t *testing.T) {
 	Equal(t, err.Error(), "AbsPkgPath is required when not using static files otherwise the static package has no idea where to grab local files from when your package is used from within another package.")
 	Equal(t, staticFiles, nil)
 }
+
+func TestLocalFileHandlesClosed(t *testing.T) {
+
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		t.Skip("/proc/self/fd not available, skipping open file descriptor count")
+	}
+
+	config := &Config{
+		UseStaticFiles: false,
+		AbsPkgPath:     getGOPATH() + "/src/github.com/go-playground/statics",
+	}
+
+	staticFiles, err := New(config, testDirFile)
+	Equal(t, err, nil)
+	NotEqual(t, staticFiles, nil)
+
+	before := countOpenFiles(t)
+
+	for i := 0; i < 100; i++ {
+
+		_, err = staticFiles.ReadFile("/static/test-files/teststart/plainfile.txt")
+		Equal(t, err, nil)
+
+		_, err = staticFiles.ReadFile("/static/test-files/teststart")
+		NotEqual(t, err, nil)
+
+		_, err = staticFiles.ReadDir("/static/test-files/teststart")
+		Equal(t, err, nil)
+
+		_, err = staticFiles.ReadDir("/static/test-files/teststart/plainfile.txt")
+		NotEqual(t, err, nil)
+
+		_, err = staticFiles.ReadFiles("/static/test-files/teststart", false)
+		Equal(t, err, nil)
+
+		_, err = staticFiles.ReadFiles("/static/test-files/teststart", true)
+		Equal(t, err, nil)
+	}
+
+	Equal(t, countOpenFiles(t), before)
+}
+
+func countOpenFiles(t *testing.T) int {
+
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	Equal(t, err, nil)
+
+	return len(fds)
+}
+
+func TestReadFilesOptions(t *testing.T) {
+
+	configs := []*Config{
+		{
+			UseStaticFiles: true,
+		},
+		{
+			UseStaticFiles: false,
+			AbsPkgPath:     getGOPATH() + "/src/github.com/go-playground/statics",
+		},
+	}
+
+	for _, config := range configs {
+
+		staticFiles, err := New(config, testDirFile)
+		Equal(t, err, nil)
+
+		bs, err := staticFiles.ReadFilesWithOptions("/static/test-files/teststart/", &ReadFilesOptions{Recursive: true})
+		Equal(t, err, nil)
+		Equal(t, sortedKeys(bs), []string{
+			"/static/test-files/teststart/plainfile.txt",
+			"/static/test-files/teststart/symlinkeddir/realdir/doublesymlinkeddir/doublesymlinkedfile.txt",
+			"/static/test-files/teststart/symlinkeddir/realdir/doublesymlinkeddir/triplesymlinkeddir/triplefile.txt",
+			"/static/test-files/teststart/symlinkeddir/realdir/realdirfile.txt",
+			"/static/test-files/teststart/symlinkeddir/symlinkeddirfile.txt",
+			"/static/test-files/teststart/symlinkedfile.txt",
+		})
+
+		bs, err = staticFiles.ReadFilesWithOptions("/static/test-files/teststart", &ReadFilesOptions{Recursive: true, RelativeKeys: true})
+		Equal(t, err, nil)
+		Equal(t, sortedKeys(bs), []string{
+			"plainfile.txt",
+			"symlinkeddir/realdir/doublesymlinkeddir/doublesymlinkedfile.txt",
+			"symlinkeddir/realdir/doublesymlinkeddir/triplesymlinkeddir/triplefile.txt",
+			"symlinkeddir/realdir/realdirfile.txt",
+			"symlinkeddir/symlinkeddirfile.txt",
+			"symlinkedfile.txt",
+		})
+		Equal(t, string(bs["plainfile.txt"]), "palindata\n")
+
+		bs, err = staticFiles.ReadFilesWithOptions("/static/test-files/teststart", &ReadFilesOptions{Recursive: true, RelativeKeys: true, Glob: "symlinkeddir/*/*.txt"})
+		Equal(t, err, nil)
+		Equal(t, sortedKeys(bs), []string{"symlinkeddir/realdir/realdirfile.txt"})
+
+		bs, err = staticFiles.ReadFilesWithOptions("/static/test-files/teststart", &ReadFilesOptions{Glob: "symlinked*"})
+		Equal(t, err, nil)
+		Equal(t, sortedKeys(bs), []string{"/static/test-files/teststart/symlinkedfile.txt"})
+
+		bs, err = staticFiles.ReadFilesWithOptions("/static/test-files/teststart", nil)
+		Equal(t, err, nil)
+		Equal(t, len(bs), 2)
+
+		bs, err = staticFiles.ReadFilesWithOptions("/static/test-files/teststart", &ReadFilesOptions{Glob: "[bad"})
+		NotEqual(t, err, nil)
+		Equal(t, bs, nil)
+
+		// keys are identical however the directory is written
+		expected, err := staticFiles.ReadFiles("/static/test-files/teststart", true)
+		Equal(t, err, nil)
+		Equal(t, len(expected), 6)
+
+		for _, dirname := range []string{"static/test-files/teststart", "/static/test-files/../test-files/teststart/", "/static//test-files/./teststart", "/static/test-files/teststart/symlinkeddir/.."} {
+
+			bs, err = staticFiles.ReadFiles(dirname, true)
+			Equal(t, err, nil)
+			Equal(t, sortedKeys(bs), sortedKeys(expected))
+		}
+
+		matches, err := staticFiles.Glob("/static/test-files/teststart/**")
+		Equal(t, err, nil)
+
+		var walked []string
+
+		err = staticFiles.Walk("static/test-files/../test-files/teststart/", func(p string, d fs.DirEntry, err error) error {
+			walked = append(walked, p)
+			return err
+		})
+		Equal(t, err, nil)
+		Equal(t, walked, matches)
+	}
+
+	staticFiles, err := NewFromMap(nil, map[string][]byte{"a/b.txt": []byte("b")})
+	Equal(t, err, nil)
+
+	for _, dirname := range []string{"", ".", "/", "a/..", "/a/../"} {
+
+		bs, err := staticFiles.ReadFiles(dirname, true)
+		Equal(t, err, nil)
+		Equal(t, sortedKeys(bs), []string{"/a/b.txt"})
+	}
+}
+
+func sortedKeys(m map[string][]byte) []string {
+
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func TestWalkAndGlob(t *testing.T) {
+
+	configs := []*Config{
+		{
+			UseStaticFiles: true,
+		},
+		{
+			UseStaticFiles: false,
+			AbsPkgPath:     getGOPATH() + "/src/github.com/go-playground/statics",
+		},
+	}
+
+	for _, config := range configs {
+
+		staticFiles, err := New(config, testDirFile)
+		Equal(t, err, nil)
+
+		var walked []string
+
+		err = staticFiles.Walk("/static/test-files/teststart", func(p string, d fs.DirEntry, err error) error {
+
+			Equal(t, err, nil)
+
+			if d.Name() == "realdir" {
+				return fs.SkipDir
+			}
+
+			walked = append(walked, p)
+
+			return nil
+		})
+		Equal(t, err, nil)
+		Equal(t, walked, []string{
+			"/static/test-files/teststart",
+			"/static/test-files/teststart/plainfile.txt",
+			"/static/test-files/teststart/symlinkeddir",
+			"/static/test-files/teststart/symlinkeddir/symlinkeddirfile.txt",
+			"/static/test-files/teststart/symlinkedfile.txt",
+		})
+
+		var walkErr error
+
+		err = staticFiles.Walk("/nonexistantdir", func(p string, d fs.DirEntry, err error) error {
+			walkErr = err
+			return err
+		})
+		NotEqual(t, err, nil)
+		Equal(t, err, walkErr)
+
+		matches, err := staticFiles.Glob("/static/test-files/teststart/**/*.txt")
+		Equal(t, err, nil)
+		Equal(t, matches, []string{
+			"/static/test-files/teststart/plainfile.txt",
+			"/static/test-files/teststart/symlinkeddir/realdir/doublesymlinkeddir/doublesymlinkedfile.txt",
+			"/static/test-files/teststart/symlinkeddir/realdir/doublesymlinkeddir/triplesymlinkeddir/triplefile.txt",
+			"/static/test-files/teststart/symlinkeddir/realdir/realdirfile.txt",
+			"/static/test-files/teststart/symlinkeddir/symlinkeddirfile.txt",
+			"/static/test-files/teststart/symlinkedfile.txt",
+		})
+
+		matches, err = staticFiles.Glob("static/test-files/teststart/*/*")
+		Equal(t, err, nil)
+		Equal(t, matches, []string{
+			"/static/test-files/teststart/symlinkeddir/realdir",
+			"/static/test-files/teststart/symlinkeddir/symlinkeddirfile.txt",
+		})
+
+		matches, err = staticFiles.Glob("/static/test-files/teststart/**/triple*")
+		Equal(t, err, nil)
+		Equal(t, matches, []string{
+			"/static/test-files/teststart/symlinkeddir/realdir/doublesymlinkeddir/triplesymlinkeddir",
+			"/static/test-files/teststart/symlinkeddir/realdir/doublesymlinkeddir/triplesymlinkeddir/triplefile.txt",
+		})
+
+		// root anchored, the directories above the generated root exist in both modes
+		matches, err = staticFiles.Glob("/**/teststart/*.txt")
+		Equal(t, err, nil)
+		Equal(t, matches, []string{
+			"/static/test-files/teststart/plainfile.txt",
+			"/static/test-files/teststart/symlinkedfile.txt",
+		})
+
+		matches, err = staticFiles.Glob("/*/test-files")
+		Equal(t, err, nil)
+		Equal(t, matches, []string{"/static/test-files"})
+
+		matches, err = staticFiles.Glob("/nonexistantdir/*")
+		Equal(t, err, nil)
+		Equal(t, len(matches), 0)
+
+		_, err = staticFiles.Glob("/static/[bad")
+		NotEqual(t, err, nil)
+
+		bs, err := staticFiles.ReadFilesWithOptions("/static/test-files/teststart", &ReadFilesOptions{Recursive: true, RelativeKeys: true, Glob: "**/double*"})
+		Equal(t, err, nil)
+		Equal(t, sortedKeys(bs), []string{"symlinkeddir/realdir/doublesymlinkeddir/doublesymlinkedfile.txt"})
+
+		_, err = staticFiles.ReadFiles("/static/test-files/teststart/plainfile.txt", false)
+		NotEqual(t, err, nil)
+	}
+
+	staticFiles, err := New(&Config{UseStaticFiles: true}, testDirFile)
+	Equal(t, err, nil)
+
+	fis, err := staticFiles.ReadDir("/")
+	Equal(t, err, nil)
+	Equal(t, len(fis), 1)
+	Equal(t, fis[0].Name(), "static")
+	Equal(t, fis[0].IsDir(), true)
+
+	var walked []string
+
+	err = staticFiles.Walk("/", func(p string, d fs.DirEntry, err error) error {
+
+		Equal(t, err, nil)
+
+		if d.Name() == "symlinkeddir" {
+			return fs.SkipDir
+		}
+
+		walked = append(walked, p)
+
+		return nil
+	})
+	Equal(t, err, nil)
+	Equal(t, walked, []string{
+		"/",
+		"/static",
+		"/static/test-files",
+		"/static/test-files/teststart",
+		"/static/test-files/teststart/plainfile.txt",
+		"/static/test-files/teststart/symlinkedfile.txt",
+	})
+}
+
+func TestStatAndExists(t *testing.T) {
+
+	configs := []*Config{
+		{
+			UseStaticFiles: true,
+		},
+		{
+			UseStaticFiles: false,
+			AbsPkgPath:     getGOPATH() + "/src/github.com/go-playground/statics",
+		},
+	}
+
+	for _, config := range configs {
+
+		staticFiles, err := New(config, testDirFile)
+		Equal(t, err, nil)
+
+		fi, err := staticFiles.Stat("/static/test-files/teststart/plainfile.txt")
+		Equal(t, err, nil)
+		Equal(t, fi.Name(), "plainfile.txt")
+		Equal(t, fi.Size(), int64(10))
+		Equal(t, fi.IsDir(), false)
+
+		fi, err = staticFiles.Stat("/static/test-files/teststart/symlinkeddir")
+		Equal(t, err, nil)
+		Equal(t, fi.Name(), "symlinkeddir")
+		Equal(t, fi.IsDir(), true)
+		Equal(t, fi.Mode()&os.ModeSymlink, os.FileMode(0))
+
+		_, err = staticFiles.Stat("/static/test-files/teststart/nonexistantfile")
+		NotEqual(t, err, nil)
+		Equal(t, os.IsNotExist(err), true)
+
+		Equal(t, staticFiles.Exists("/static/test-files/teststart/symlinkedfile.txt"), true)
+		Equal(t, staticFiles.Exists("/static/test-files/teststart/nonexistantfile"), false)
+		Equal(t, staticFiles.Exists("/static/test-files/symlinkedfile.txt"), !config.UseStaticFiles)
+	}
+
+	config := &Config{
+		UseStaticFiles: true,
+		FallbackToDisk: true,
+		AbsPkgPath:     getGOPATH() + "/src/github.com/go-playground/statics",
+	}
+
+	staticFiles, err := New(config, testDirFile)
+	Equal(t, err, nil)
+
+	fi, err := staticFiles.Stat("/static/test-files/symlinkedfile.txt")
+	Equal(t, err, nil)
+	Equal(t, fi.Name(), "symlinkedfile.txt")
+	Equal(t, staticFiles.Exists("/static/test-files/teststart/plainfile.txt"), true)
+	Equal(t, staticFiles.Exists("/static/test-files/nonexistantfile"), false)
+}
+
+func TestSub(t *testing.T) {
+
+	configs := []*Config{
+		{
+			UseStaticFiles: true,
+		},
+		{
+			UseStaticFiles: false,
+			AbsPkgPath:     getGOPATH() + "/src/github.com/go-playground/statics",
+		},
+	}
+
+	for _, config := range configs {
+
+		staticFiles, err := New(config, testDirFile)
+		Equal(t, err, nil)
+
+		sub, err := staticFiles.Sub("/static/test-files/teststart/")
+		Equal(t, err, nil)
+		NotEqual(t, sub, nil)
+
+		b, err := sub.ReadFile("/plainfile.txt")
+		Equal(t, err, nil)
+		Equal(t, string(b), "palindata\n")
+
+		b, err = sub.ReadFile("symlinkedfile.txt")
+		Equal(t, err, nil)
+		Equal(t, string(b), "data\n")
+
+		fis, err := sub.ReadDir("/")
+		Equal(t, err, nil)
+		Equal(t, len(fis), 3)
+		Equal(t, fis[1].Name(), "symlinkeddir")
+		Equal(t, fis[1].IsDir(), true)
+
+		bs, err := sub.ReadFiles("/", true)
+		Equal(t, err, nil)
+		Equal(t, len(bs), 6)
+		Equal(t, string(bs["/symlinkeddir/symlinkeddirfile.txt"]), "data\n")
+
+		f, err := sub.FS().Open("/plainfile.txt")
+		Equal(t, err, nil)
+
+		fi, err := f.Stat()
+		Equal(t, err, nil)
+		Equal(t, fi.Name(), "plainfile.txt")
+		Equal(t, f.Close(), nil)
+
+		Equal(t, sub.Exists("/symlinkeddir/realdir"), true)
+
+		nested, err := sub.Sub("symlinkeddir")
+		Equal(t, err, nil)
+
+		b, err = nested.ReadFile("/realdir/realdirfile.txt")
+		Equal(t, err, nil)
+		Equal(t, string(b), "data\n")
+
+		// can never escape the sub directory
+		_, err = nested.ReadFile("/../symlinkedfile.txt")
+		NotEqual(t, err, nil)
+		Equal(t, nested.Exists("/../plainfile.txt"), false)
+
+		_, err = sub.Sub("/plainfile.txt")
+		NotEqual(t, err, nil)
+
+		_, err = sub.Sub("/nonexistantdir")
+		NotEqual(t, err, nil)
+	}
+}
+
+func TestOverlay(t *testing.T) {
+
+	base, err := New(&Config{UseStaticFiles: true}, testDirFile)
+	Equal(t, err, nil)
+
+	dir := t.TempDir()
+	start := filepath.Join(dir, "static", "test-files", "teststart")
+
+	Equal(t, os.MkdirAll(filepath.Join(start, "symlinkeddir"), 0755), nil)
+	Equal(t, os.MkdirAll(filepath.Join(start, "emptydir"), 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(start, "plainfile.txt"), []byte("override\n"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(start, "extra.txt"), []byte("extra\n"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(start, WhiteoutPrefix+"symlinkedfile.txt"), nil, 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(start, "symlinkeddir", WhiteoutPrefix+"realdir"), nil, 0644), nil)
+
+	top, err := New(&Config{UseStaticFiles: false, AbsPkgPath: dir}, nil)
+	Equal(t, err, nil)
+
+	overlay := Overlay(top, nil, base)
+
+	b, err := overlay.ReadFile("/static/test-files/teststart/plainfile.txt")
+	Equal(t, err, nil)
+	Equal(t, string(b), "override\n")
+
+	b, err = overlay.ReadFile("/static/test-files/teststart/extra.txt")
+	Equal(t, err, nil)
+	Equal(t, string(b), "extra\n")
+
+	b, err = overlay.ReadFile("/static/test-files/teststart/symlinkeddir/symlinkeddirfile.txt")
+	Equal(t, err, nil)
+	Equal(t, string(b), "data\n")
+
+	// whited out in the top layer
+	_, err = overlay.ReadFile("/static/test-files/teststart/symlinkedfile.txt")
+	NotEqual(t, err, nil)
+	Equal(t, overlay.Exists("/static/test-files/teststart/symlinkeddir/realdir"), false)
+	Equal(t, overlay.Exists("/static/test-files/teststart/symlinkeddir/realdir/realdirfile.txt"), false)
+	Equal(t, overlay.Exists("/static/test-files/teststart/"+WhiteoutPrefix+"symlinkedfile.txt"), false)
+
+	fi, err := overlay.Stat("/static/test-files/teststart/plainfile.txt")
+	Equal(t, err, nil)
+	Equal(t, fi.Size(), int64(9))
+
+	fis, err := overlay.ReadDir("/static/test-files/teststart")
+	Equal(t, err, nil)
+	Equal(t, len(fis), 4)
+	Equal(t, fis[0].Name(), "emptydir")
+	Equal(t, fis[1].Name(), "extra.txt")
+	Equal(t, fis[2].Name(), "plainfile.txt")
+	Equal(t, fis[3].Name(), "symlinkeddir")
+
+	fis, err = overlay.ReadDir("/static/test-files/teststart/symlinkeddir")
+	Equal(t, err, nil)
+	Equal(t, len(fis), 1)
+	Equal(t, fis[0].Name(), "symlinkeddirfile.txt")
+
+	f, err := overlay.FS().Open("/static/test-files/teststart")
+	Equal(t, err, nil)
+
+	fis, err = f.Readdir(3)
+	Equal(t, err, nil)
+	Equal(t, len(fis), 3)
+
+	fis, err = f.Readdir(3)
+	Equal(t, err, nil)
+	Equal(t, len(fis), 1)
+
+	fis, err = f.Readdir(3)
+	Equal(t, err, io.EOF)
+	Equal(t, len(fis), 0)
+	Equal(t, f.Close(), nil)
+
+	bs, err := overlay.ReadFiles("/static/test-files/teststart", true)
+	Equal(t, err, nil)
+	Equal(t, sortedKeys(bs), []string{
+		"/static/test-files/teststart/extra.txt",
+		"/static/test-files/teststart/plainfile.txt",
+		"/static/test-files/teststart/symlinkeddir/symlinkeddirfile.txt",
+	})
+
+	sub, err := overlay.Sub("/static/test-files/teststart")
+	Equal(t, err, nil)
+
+	b, err = sub.ReadFile("/plainfile.txt")
+	Equal(t, err, nil)
+	Equal(t, string(b), "override\n")
+
+	_, err = overlay.ReadFile("/nonexistantfile")
+	NotEqual(t, err, nil)
+	Equal(t, os.IsNotExist(err), true)
+}
+
+func TestFallbackToDiskListings(t *testing.T) {
+
+	dir := t.TempDir()
+	start := filepath.Join(dir, "static", "test-files", "teststart")
+
+	Equal(t, os.MkdirAll(filepath.Join(dir, "uploads"), 0755), nil)
+	Equal(t, os.MkdirAll(start, 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(start, "plainfile.txt"), []byte("disk\n"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(start, "avatar.png"), []byte("png"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(dir, "uploads", "upload.txt"), []byte("upload\n"), 0644), nil)
+
+	config := &Config{
+		UseStaticFiles: true,
+		FallbackToDisk: true,
+		AbsPkgPath:     dir,
+	}
+
+	staticFiles, err := New(config, testDirFile)
+	Equal(t, err, nil)
+
+	fis, err := staticFiles.ReadDir("/static/test-files/teststart")
+	Equal(t, err, nil)
+	Equal(t, len(fis), 4)
+	Equal(t, fis[0].Name(), "avatar.png")
+	Equal(t, fis[1].Name(), "plainfile.txt")
+	Equal(t, fis[1].Size(), int64(10))
+	Equal(t, fis[2].Name(), "symlinkeddir")
+	Equal(t, fis[3].Name(), "symlinkedfile.txt")
+
+	b, err := staticFiles.ReadFile("/static/test-files/teststart/plainfile.txt")
+	Equal(t, err, nil)
+	Equal(t, string(b), "palindata\n")
+
+	b, err = staticFiles.ReadFile("/static/test-files/teststart/avatar.png")
+	Equal(t, err, nil)
+	Equal(t, string(b), "png")
+
+	fis, err = staticFiles.ReadDir("/uploads")
+	Equal(t, err, nil)
+	Equal(t, len(fis), 1)
+	Equal(t, fis[0].Name(), "upload.txt")
+
+	config.FallbackPrecedence = PreferDisk
+
+	staticFiles, err = New(config, testDirFile)
+	Equal(t, err, nil)
+
+	fis, err = staticFiles.ReadDir("/static/test-files/teststart")
+	Equal(t, err, nil)
+	Equal(t, len(fis), 4)
+	Equal(t, fis[1].Name(), "plainfile.txt")
+	Equal(t, fis[1].Size(), int64(5))
+
+	b, err = staticFiles.ReadFile("/static/test-files/teststart/plainfile.txt")
+	Equal(t, err, nil)
+	Equal(t, string(b), "disk\n")
+
+	b, err = staticFiles.ReadFile("/static/test-files/teststart/symlinkedfile.txt")
+	Equal(t, err, nil)
+	Equal(t, string(b), "data\n")
+
+	// whiteouts only apply to Overlay, on disk they are served like any other file
+	Equal(t, ioutil.WriteFile(filepath.Join(start, ".wh.symlinkedfile.txt"), []byte("upload\n"), 0644), nil)
+
+	for _, precedence := range []Precedence{PreferStatic, PreferDisk} {
+
+		config.FallbackPrecedence = precedence
+
+		staticFiles, err = New(config, testDirFile)
+		Equal(t, err, nil)
+
+		Equal(t, staticFiles.Exists("/static/test-files/teststart/symlinkedfile.txt"), true)
+
+		b, err = staticFiles.ReadFile("/static/test-files/teststart/.wh.symlinkedfile.txt")
+		Equal(t, err, nil)
+		Equal(t, string(b), "upload\n")
+
+		fis, err = staticFiles.ReadDir("/static/test-files/teststart")
+		Equal(t, err, nil)
+		Equal(t, len(fis), 5)
+		Equal(t, fis[0].Name(), ".wh.symlinkedfile.txt")
+		Equal(t, fis[4].Name(), "symlinkedfile.txt")
+	}
+}
+
+func TestFallbackDirs(t *testing.T) {
+
+	uploads := t.TempDir()
+	shared := t.TempDir()
+
+	Equal(t, ioutil.WriteFile(filepath.Join(uploads, "avatar.png"), []byte("png"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(shared, "avatar.png"), []byte("shared png"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(shared, "robots.txt"), []byte("robots"), 0644), nil)
+
+	configs := []*Config{
+		{
+			UseStaticFiles: true,
+			FallbackDirs: []FallbackDir{
+				{Dir: uploads, Prefix: "/media/uploads"},
+				{Dir: shared},
+			},
+		},
+		{
+			UseStaticFiles: false,
+			AbsPkgPath:     getGOPATH() + "/src/github.com/go-playground/statics",
+			FallbackDirs: []FallbackDir{
+				{Dir: uploads, Prefix: "/media/uploads/"},
+				{Dir: shared},
+			},
+		},
+	}
+
+	for _, config := range configs {
+
+		staticFiles, err := New(config, testDirFile)
+		Equal(t, err, nil)
+
+		b, err := staticFiles.ReadFile("/static/test-files/teststart/plainfile.txt")
+		Equal(t, err, nil)
+		Equal(t, string(b), "palindata\n")
+
+		b, err = staticFiles.ReadFile("/media/uploads/avatar.png")
+		Equal(t, err, nil)
+		Equal(t, string(b), "png")
+
+		b, err = staticFiles.ReadFile("/avatar.png")
+		Equal(t, err, nil)
+		Equal(t, string(b), "shared png")
+
+		b, err = staticFiles.ReadFile("/robots.txt")
+		Equal(t, err, nil)
+		Equal(t, string(b), "robots")
+
+		fis, err := staticFiles.ReadDir("/media")
+		Equal(t, err, nil)
+		Equal(t, len(fis), 1)
+		Equal(t, fis[0].Name(), "uploads")
+		Equal(t, fis[0].IsDir(), true)
+
+		fis, err = staticFiles.ReadDir("/media/uploads")
+		Equal(t, err, nil)
+		Equal(t, len(fis), 1)
+		Equal(t, fis[0].Name(), "avatar.png")
+
+		// can never escape a fallback directory
+		_, err = staticFiles.ReadFile("/media/uploads/../../" + filepath.Base(shared) + "/robots.txt")
+		NotEqual(t, err, nil)
+		Equal(t, os.IsNotExist(err), true)
+
+		_, err = staticFiles.ReadFile("/media/nonexistantfile")
+		NotEqual(t, err, nil)
+		Equal(t, os.IsNotExist(err), true)
+
+		// the same errors as local mode, a path through a file isn't "not exist"
+		_, err = staticFiles.ReadFile("/robots.txt/x")
+		NotEqual(t, err, nil)
+		Equal(t, os.IsNotExist(err), false)
+
+		_, err = staticFiles.Stat("/robots.txt/x")
+		NotEqual(t, err, nil)
+		Equal(t, os.IsNotExist(err), false)
+	}
+
+	// whiteouts only apply to Overlay, an upload can never hide another file
+	Equal(t, ioutil.WriteFile(filepath.Join(uploads, ".wh.robots.txt"), []byte("upload"), 0644), nil)
+
+	staticFiles, err := New(&Config{
+		UseStaticFiles: true,
+		FallbackDirs:   []FallbackDir{{Dir: uploads}, {Dir: shared}},
+	}, testDirFile)
+	Equal(t, err, nil)
+
+	b, err := staticFiles.ReadFile("/robots.txt")
+	Equal(t, err, nil)
+	Equal(t, string(b), "robots")
+
+	b, err = staticFiles.ReadFile("/.wh.robots.txt")
+	Equal(t, err, nil)
+	Equal(t, string(b), "upload")
+
+	fis, err := staticFiles.ReadDir("/")
+	Equal(t, err, nil)
+	Equal(t, len(fis), 4)
+	Equal(t, fis[0].Name(), ".wh.robots.txt")
+	Equal(t, fis[2].Name(), "robots.txt")
+	Equal(t, fis[3].Name(), "static")
+
+	config := &Config{
+		UseStaticFiles: true,
+		FallbackDirs:   []FallbackDir{{Dir: "../uploads"}},
+	}
+
+	staticFiles, err = New(config, testDirFile)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "FallbackDirs[0].Dir '../uploads' must be an absolute path")
+	Equal(t, staticFiles, nil)
+}
+
+func TestLiveReload(t *testing.T) {
+
+	dir := t.TempDir()
+
+	Equal(t, ioutil.WriteFile(filepath.Join(dir, "app.css"), []byte("body {}"), 0644), nil)
+
+	config := &Config{
+		UseStaticFiles: false,
+		AbsPkgPath:     dir,
+	}
+
+	files, err := New(config, &DirFile{})
+	Equal(t, err, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lr := NewLiveReload(ctx, files, &LiveReloadConfig{Path: "/_livereload", Interval: 5 * time.Millisecond})
+
+	mux := http.NewServeMux()
+	mux.Handle("/_livereload", lr)
+	mux.Handle("/", lr.Inject(http.FileServer(files.FS())))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	Equal(t, lr.Script(), `<script>(function(){var s=new EventSource("/_livereload");s.addEventListener("reload",function(){s.close();location.reload();});})();</script>`)
+
+	Equal(t, ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<html><body><p>hi</p></body></html>"), 0644), nil)
+
+	resp, err := http.Get(server.URL + "/index.html")
+	Equal(t, err, nil)
+
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	Equal(t, err, nil)
+	Equal(t, string(b), "<html><body><p>hi</p>"+lr.Script()+"</body></html>")
+
+	resp, err = http.Get(server.URL + "/app.css")
+	Equal(t, err, nil)
+
+	b, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	Equal(t, err, nil)
+	Equal(t, string(b), "body {}")
+
+	resp, err = http.Get(server.URL + "/_livereload")
+	Equal(t, err, nil)
+	defer resp.Body.Close()
+
+	Equal(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	events := make(chan string, 10)
+
+	go func() {
+
+		scanner := bufio.NewScanner(resp.Body)
+
+		for scanner.Scan() {
+
+			// events are separated by blank lines
+			if len(scanner.Text()) > 0 {
+				events <- scanner.Text()
+			}
+		}
+
+		close(events)
+	}()
+
+	Equal(t, <-events, ": connected")
+
+	// wait for the first snapshot after connecting before changing anything
+	time.Sleep(50 * time.Millisecond)
+
+	Equal(t, ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("alert(1)"), 0644), nil)
+
+	var event string
+
+	select {
+	case event = <-events:
+	case <-time.After(10 * time.Second):
+	}
+
+	Equal(t, event, "event: reload")
+	Equal(t, strings.HasPrefix(<-events, "data: "), true)
+
+	// responses other than html are passed straight through, not buffered
+	for _, contentType := range []string{"text/css; charset=utf-8", ""} {
+
+		rec := httptest.NewRecorder()
+		streamed := false
+
+		lr.Inject(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			if len(contentType) > 0 {
+				w.Header().Set("Content-Type", contentType)
+				w.WriteHeader(http.StatusOK)
+			}
+
+			w.Write([]byte("\x00\x01binary"))
+			streamed = rec.Body.Len() > 0
+		})).ServeHTTP(rec, httptest.NewRequest("GET", "/app.bin", nil))
+
+		Equal(t, streamed, true)
+		Equal(t, rec.Code, http.StatusOK)
+		Equal(t, rec.Body.String(), "\x00\x01binary")
+	}
+
+	// an encoded body is passed through untouched
+	rec := httptest.NewRecorder()
+
+	lr.Inject(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("\x1f\x8b</body>"))
+	})).ServeHTTP(rec, httptest.NewRequest("GET", "/index.html", nil))
+
+	Equal(t, rec.Code, http.StatusOK)
+	Equal(t, rec.Body.String(), "\x1f\x8b</body>")
+
+	// as are HEAD responses, keeping the Content-Length of the GET
+	req, err := http.NewRequest("HEAD", server.URL+"/index.html", nil)
+	Equal(t, err, nil)
+
+	resp, err = http.DefaultClient.Do(req)
+	Equal(t, err, nil)
+	resp.Body.Close()
+	Equal(t, resp.StatusCode, http.StatusOK)
+	Equal(t, resp.ContentLength, int64(len("<html><body><p>hi</p></body></html>")))
+
+	rec = httptest.NewRecorder()
+
+	lr.Inject(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<html><body>missing</body></html>", http.StatusNotFound)
+	})).ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+
+	Equal(t, rec.Code, http.StatusNotFound)
+	Equal(t, rec.Body.String(), "<html><body>missing</body></html>\n")
+
+	// cancelling the context closes connected clients, so shutdown isn't held up
+	cancel()
+
+	closed := false
+
+	select {
+	case _, open := <-events:
+		closed = !open
+	case <-time.After(10 * time.Second):
+	}
+
+	Equal(t, closed, true)
+}
+
+func TestLocation(t *testing.T) {
+
+	root := t.TempDir()
+	web := filepath.Join(root, "web")
+
+	Equal(t, os.MkdirAll(filepath.Join(web, "assets"), 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(root, "go.mod"), []byte("// comment\nmodule \"example.com/project\" // trailing\n\ngo 1.16\n"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(web, "assets", "app.js"), []byte("alert(1)"), 0644), nil)
+
+	// resolved from the source location, the working directory being in another module
+	loc := &Location{
+		Module: "example.com/project",
+		Dir:    "web",
+		Source: filepath.Join(web, "assets.go"),
+	}
+
+	abs, err := loc.AbsPkgPath()
+	Equal(t, err, nil)
+	Equal(t, abs, web)
+
+	config := &Config{
+		UseStaticFiles: false,
+		Location:       loc,
+	}
+
+	files, err := New(config, &DirFile{})
+	Equal(t, err, nil)
+	Equal(t, config.AbsPkgPath, web)
+
+	b, err := files.ReadFile("/assets/app.js")
+	Equal(t, err, nil)
+	Equal(t, string(b), "alert(1)")
+
+	// resolved from the working directory
+	wd, err := os.Getwd()
+	Equal(t, err, nil)
+	Equal(t, os.Chdir(filepath.Join(web, "assets")), nil)
+
+	abs, err = (&Location{Module: "example.com/project", Dir: "web"}).AbsPkgPath()
+	Equal(t, os.Chdir(wd), nil)
+	Equal(t, err, nil)
+	Equal(t, abs, web)
+
+	// the environment variable takes precedence
+	other := t.TempDir()
+	t.Setenv(EnvModuleDir, other)
+
+	abs, err = loc.AbsPkgPath()
+	Equal(t, err, nil)
+	Equal(t, abs, filepath.Join(other, "web"))
+
+	t.Setenv(EnvModuleDir, "relative")
+
+	_, err = loc.AbsPkgPath()
+	NotEqual(t, err, nil)
+
+	t.Setenv(EnvModuleDir, "")
+
+	_, err = (&Location{Module: "example.com/missing", Dir: "web"}).AbsPkgPath()
+	NotEqual(t, err, nil)
+
+	// static files only need resolving when falling back to disk
+	config = &Config{
+		UseStaticFiles: true,
+		Location:       &Location{Module: "example.com/missing"},
+	}
+
+	_, err = New(config, &DirFile{Path: "/", IsDir: true})
+	Equal(t, err, nil)
+
+	// nor is failing to resolve an error, i.e. a deployed binary with no go.mod,
+	// falling back to disk relative to the working directory instead
+	deployed := t.TempDir()
+	Equal(t, ioutil.WriteFile(filepath.Join(deployed, "avatar.png"), []byte("png"), 0644), nil)
+
+	config = &Config{
+		UseStaticFiles: true,
+		FallbackToDisk: true,
+		Location: &Location{
+			Module: "example.com/missing",
+			Dir:    "web",
+			Source: filepath.Join(t.TempDir(), "assets.go"),
+		},
+	}
+
+	Equal(t, os.Chdir(deployed), nil)
+	defer os.Chdir(wd)
+
+	files, err = New(config, &DirFile{Path: "/", IsDir: true})
+	Equal(t, err, nil)
+	Equal(t, config.AbsPkgPath, "")
+
+	b, err = files.ReadFile("/avatar.png")
+	Equal(t, err, nil)
+	Equal(t, string(b), "png")
+
+	// local mode has nowhere else to read the files from
+	config.UseStaticFiles = false
+
+	_, err = New(config, &DirFile{})
+	NotEqual(t, err, nil)
+}
+
+func TestNewFromFS(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"assets/app.js":      {Data: []byte("alert(1)")},
+		"assets/css/app.css": {Data: []byte("body {}")},
+	}
+
+	_, err := NewFromFS(&Config{UseStaticFiles: true}, nil)
+	NotEqual(t, err, nil)
+
+	files, err := NewFromFS(&Config{UseStaticFiles: true}, fsys)
+	Equal(t, err, nil)
+
+	b, err := files.ReadFile("/assets/css/app.css")
+	Equal(t, err, nil)
+	Equal(t, string(b), "body {}")
+
+	fis, err := files.ReadDir("/assets")
+	Equal(t, err, nil)
+	Equal(t, len(fis), 2)
+	Equal(t, fis[0].Name(), "app.js")
+	Equal(t, fis[1].Name(), "css")
+	Equal(t, fis[1].IsDir(), true)
+
+	Equal(t, files.Exists("/assets/app.js"), true)
+	Equal(t, files.Exists("/assets/missing.js"), false)
+
+	_, err = files.GetHTTPFile("/assets/missing.js")
+	Equal(t, os.IsNotExist(err), true)
+
+	all, err := files.ReadFiles("/", true)
+	Equal(t, err, nil)
+	Equal(t, sortedKeys(all), []string{"/assets/app.js", "/assets/css/app.css"})
+
+	matches, err := files.Glob("/**/*.css")
+	Equal(t, err, nil)
+	Equal(t, matches, []string{"/assets/css/app.css"})
+
+	// local mode and falling back to disk behave as with New
+	dir := t.TempDir()
+
+	Equal(t, os.MkdirAll(filepath.Join(dir, "assets"), 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(dir, "assets", "app.js"), []byte("alert(2)"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(dir, "assets", "disk.js"), []byte("disk"), 0644), nil)
+
+	files, err = NewFromFS(&Config{UseStaticFiles: false, AbsPkgPath: dir}, fsys)
+	Equal(t, err, nil)
+
+	b, err = files.ReadFile("/assets/app.js")
+	Equal(t, err, nil)
+	Equal(t, string(b), "alert(2)")
+	Equal(t, files.Exists("/assets/css/app.css"), false)
+
+	files, err = NewFromFS(&Config{UseStaticFiles: true, FallbackToDisk: true, AbsPkgPath: dir}, fsys)
+	Equal(t, err, nil)
+
+	b, err = files.ReadFile("/assets/app.js")
+	Equal(t, err, nil)
+	Equal(t, string(b), "alert(1)")
+
+	b, err = files.ReadFile("/assets/disk.js")
+	Equal(t, err, nil)
+	Equal(t, string(b), "disk")
+}
+
+func TestNewFromMap(t *testing.T) {
+
+	files, err := NewFromMap(nil, map[string][]byte{
+		"index.html":         []byte("<html></html>"),
+		"/assets/app.js":     []byte("alert(1)"),
+		"assets/css/app.css": []byte("body {}"),
+	})
+	Equal(t, err, nil)
+
+	b, err := files.ReadFile("/assets/css/app.css")
+	Equal(t, err, nil)
+	Equal(t, string(b), "body {}")
+
+	fi, err := files.Stat("/assets/app.js")
+	Equal(t, err, nil)
+	Equal(t, fi.Name(), "app.js")
+	Equal(t, fi.Size(), int64(8))
+	Equal(t, fi.IsDir(), false)
+
+	fi, err = files.Stat("/assets")
+	Equal(t, err, nil)
+	Equal(t, fi.IsDir(), true)
+
+	fis, err := files.ReadDir("/")
+	Equal(t, err, nil)
+	Equal(t, len(fis), 2)
+	Equal(t, fis[0].Name(), "assets")
+	Equal(t, fis[1].Name(), "index.html")
+
+	all, err := files.ReadFiles("/", true)
+	Equal(t, err, nil)
+	Equal(t, sortedKeys(all), []string{"/assets/app.js", "/assets/css/app.css", "/index.html"})
+
+	var walked []string
+
+	err = files.Walk("/", func(path string, d fs.DirEntry, err error) error {
+		walked = append(walked, path)
+		return err
+	})
+	Equal(t, err, nil)
+	Equal(t, walked, []string{"/", "/assets", "/assets/app.js", "/assets/css", "/assets/css/app.css", "/index.html"})
+
+	resp := httptest.NewRecorder()
+	http.FileServer(files.FS()).ServeHTTP(resp, httptest.NewRequest("GET", "/assets/app.js", nil))
+	Equal(t, resp.Code, http.StatusOK)
+	Equal(t, resp.Body.String(), "alert(1)")
+
+	_, err = NewFromMap(nil, map[string][]byte{"a": nil, "a/b": nil})
+	NotEqual(t, err, nil)
+
+	_, err = NewFromMap(nil, map[string][]byte{"a/b": nil, "a": nil, "c": nil})
+	NotEqual(t, err, nil)
+
+	_, err = NewFromMap(nil, map[string][]byte{"/": nil})
+	NotEqual(t, err, nil)
+}
+
+func TestNewFromFSSources(t *testing.T) {
+
+	var buff bytes.Buffer
+
+	zw := zip.NewWriter(&buff)
+
+	for name, contents := range map[string]string{"assets/app.js": "alert(1)", "assets/css/app.css": "body {}"} {
+		w, err := zw.Create(name)
+		Equal(t, err, nil)
+		_, err = w.Write([]byte(contents))
+		Equal(t, err, nil)
+	}
+
+	Equal(t, zw.Close(), nil)
+
+	zr, err := zip.NewReader(bytes.NewReader(buff.Bytes()), int64(buff.Len()))
+	Equal(t, err, nil)
+
+	dir := t.TempDir()
+
+	Equal(t, os.MkdirAll(filepath.Join(dir, "real", "css"), 0755), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(dir, "real", "app.js"), []byte("alert(1)"), 0644), nil)
+	Equal(t, ioutil.WriteFile(filepath.Join(dir, "real", "css", "app.css"), []byte("body {}"), 0644), nil)
+	Equal(t, os.Symlink(filepath.Join(dir, "real"), filepath.Join(dir, "assets")), nil)
+
+	sources := map[string]fs.FS{
+		"zip":   zr,
+		"dirfs": os.DirFS(dir),
+		"mapfs": fstest.MapFS{
+			"assets/app.js":      {Data: []byte("alert(1)")},
+			"assets/css/app.css": {Data: []byte("body {}")},
+		},
+	}
+
+	for name, fsys := range sources {
+
+		files, err := NewFromFS(nil, fsys)
+		Equal(t, err, nil)
+
+		fis, err := files.ReadDir("/")
+		Equal(t, err, nil)
+
+		var assets os.FileInfo
+
+		for _, fi := range fis {
+			if fi.Name() == "assets" {
+				assets = fi
+			}
+		}
+
+		// symlinks are followed, as for local files
+		NotEqual(t, assets, nil)
+		Equal(t, assets.IsDir(), true)
+
+		all, err := files.ReadFiles("/assets", true)
+		Equal(t, err, nil)
+
+		if len(all) != 2 || string(all["/assets/app.js"]) != "alert(1)" || string(all["/assets/css/app.css"]) != "body {}" {
+			t.Fatalf("%s: unexpected files %v", name, sortedKeys(all))
+		}
+	}
+}
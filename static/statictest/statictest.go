@@ -0,0 +1,335 @@
+/*
+Package statictest provides utilities for testing code using *static.Files,
+an in-memory builder for faking static files, assertion helpers and a
+conformance suite checking static and local files behave identically.
+
+	files := statictest.NewBuilder().
+		File("/assets/app.js", "alert(1)").
+		File("/assets/css/app.css", "body {}").
+		Build(t)
+
+	statictest.AssertContents(t, files, "/assets/app.js", "alert(1)")
+	statictest.AssertListing(t, files, "/assets", "app.js", "css")
+*/
+package statictest
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/statics/static"
+)
+
+// Builder builds *static.Files from in-memory files
+type Builder struct {
+	files map[string][]byte
+}
+
+// NewBuilder returns a new empty Builder
+func NewBuilder() *Builder {
+	return &Builder{files: map[string][]byte{}}
+}
+
+// File adds, or replaces, a file; parent directories are created as needed
+func (b *Builder) File(name string, contents string) *Builder {
+	return b.Bytes(name, []byte(contents))
+}
+
+// Bytes adds, or replaces, a file with binary contents; parent directories are
+// created as needed
+func (b *Builder) Bytes(name string, contents []byte) *Builder {
+	b.files[path.Clean("/"+name)] = contents
+	return b
+}
+
+// Build returns the files in static mode, failing the test on error
+func (b *Builder) Build(t testing.TB) *static.Files {
+
+	t.Helper()
+
+	files, err := static.NewFromMap(nil, b.files)
+	if err != nil {
+		t.Fatalf("statictest: building static files: %s", err)
+	}
+
+	return files
+}
+
+// BuildLocal writes the files to a temporary directory, removed when the test
+// completes, and returns them in local mode, failing the test on error
+func (b *Builder) BuildLocal(t testing.TB) *static.Files {
+
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, contents := range b.files {
+
+		p := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("statictest: writing local files: %s", err)
+		}
+
+		if err := ioutil.WriteFile(p, contents, 0644); err != nil {
+			t.Fatalf("statictest: writing local files: %s", err)
+		}
+	}
+
+	files, err := static.New(&static.Config{UseStaticFiles: false, AbsPkgPath: dir}, &static.DirFile{})
+	if err != nil {
+		t.Fatalf("statictest: building local files: %s", err)
+	}
+
+	return files
+}
+
+// AssertExists reports an error if name doesn't exist
+func AssertExists(t testing.TB, files *static.Files, name string) {
+
+	t.Helper()
+
+	if !files.Exists(name) {
+		t.Errorf("statictest: expected '%s' to exist", name)
+	}
+}
+
+// AssertNotExists reports an error if name exists
+func AssertNotExists(t testing.TB, files *static.Files, name string) {
+
+	t.Helper()
+
+	if files.Exists(name) {
+		t.Errorf("statictest: expected '%s' not to exist", name)
+	}
+}
+
+// AssertContents reports an error if the contents of the file name are not expected
+func AssertContents(t testing.TB, files *static.Files, name string, expected string) {
+
+	t.Helper()
+
+	b, err := files.ReadFile(name)
+	if err != nil {
+		t.Errorf("statictest: reading '%s': %s", name, err)
+		return
+	}
+
+	if string(b) != expected {
+		t.Errorf("statictest: contents of '%s' are %q, expected %q", name, b, expected)
+	}
+}
+
+// AssertListing reports an error if the names of the entries in the directory
+// dirname, in any order, are not expected
+func AssertListing(t testing.TB, files *static.Files, dirname string, expected ...string) {
+
+	t.Helper()
+
+	fis, err := files.ReadDir(dirname)
+	if err != nil {
+		t.Errorf("statictest: reading directory '%s': %s", dirname, err)
+		return
+	}
+
+	names := make([]string, len(fis))
+
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+
+	sorted := append([]string(nil), expected...)
+	sort.Strings(sorted)
+
+	if !equal(names, sorted) {
+		t.Errorf("statictest: listing of '%s' is %q, expected %q", dirname, names, sorted)
+	}
+}
+
+// Conformance reports an error for every way the files in static mode behave
+// differently to those in local mode, both being walked from their root; use
+// Files.Sub on both to compare a sub directory. Walking, stat, reading files,
+// directory listings and serving both files and directory listings over http
+// are compared. File modes and
+// modification times are not, as they legitimately differ for in-memory files.
+func Conformance(t testing.TB, staticFiles, localFiles *static.Files) {
+
+	t.Helper()
+
+	staticPaths, err := walk(staticFiles)
+	if err != nil {
+		t.Errorf("statictest: walking static files: %s", err)
+		return
+	}
+
+	localPaths, err := walk(localFiles)
+	if err != nil {
+		t.Errorf("statictest: walking local files: %s", err)
+		return
+	}
+
+	if !equal(staticPaths, localPaths) {
+		t.Errorf("statictest: static files walk %q, local files walk %q", staticPaths, localPaths)
+		return
+	}
+
+	for _, name := range staticPaths {
+
+		sfi, err := staticFiles.Stat(name)
+		if err != nil {
+			t.Errorf("statictest: stat of static '%s': %s", name, err)
+			continue
+		}
+
+		lfi, err := localFiles.Stat(name)
+		if err != nil {
+			t.Errorf("statictest: stat of local '%s': %s", name, err)
+			continue
+		}
+
+		if name != "/" && sfi.Name() != lfi.Name() {
+			t.Errorf("statictest: name of '%s' is %q static, %q local", name, sfi.Name(), lfi.Name())
+		}
+
+		if sfi.IsDir() != lfi.IsDir() {
+			t.Errorf("statictest: '%s' is a directory %t static, %t local", name, sfi.IsDir(), lfi.IsDir())
+			continue
+		}
+
+		if sfi.IsDir() {
+			conformListing(t, staticFiles, localFiles, name)
+			conformHTTP(t, staticFiles, localFiles, strings.TrimSuffix(name, "/")+"/")
+			continue
+		}
+
+		if sfi.Size() != lfi.Size() {
+			t.Errorf("statictest: size of '%s' is %d static, %d local", name, sfi.Size(), lfi.Size())
+		}
+
+		conformContents(t, staticFiles, localFiles, name)
+		conformHTTP(t, staticFiles, localFiles, name)
+	}
+
+	missing := "/statictest-missing-file"
+
+	if _, err := staticFiles.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("statictest: stat of missing static file returned %v, expected not exist", err)
+	}
+
+	if _, err := localFiles.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("statictest: stat of missing local file returned %v, expected not exist", err)
+	}
+}
+
+func conformListing(t testing.TB, staticFiles, localFiles *static.Files, name string) {
+
+	t.Helper()
+
+	sfis, serr := staticFiles.ReadDir(name)
+	lfis, lerr := localFiles.ReadDir(name)
+
+	if serr != nil || lerr != nil {
+		t.Errorf("statictest: reading directory '%s': %v static, %v local", name, serr, lerr)
+		return
+	}
+
+	snames, lnames := make([]string, len(sfis)), make([]string, len(lfis))
+
+	for i, fi := range sfis {
+		snames[i] = fi.Name()
+	}
+
+	for i, fi := range lfis {
+		lnames[i] = fi.Name()
+	}
+
+	if !equal(snames, lnames) {
+		t.Errorf("statictest: listing of '%s' is %q static, %q local", name, snames, lnames)
+	}
+}
+
+func conformContents(t testing.TB, staticFiles, localFiles *static.Files, name string) {
+
+	t.Helper()
+
+	sb, serr := staticFiles.ReadFile(name)
+	lb, lerr := localFiles.ReadFile(name)
+
+	if serr != nil || lerr != nil {
+		t.Errorf("statictest: reading '%s': %v static, %v local", name, serr, lerr)
+		return
+	}
+
+	if string(sb) != string(lb) {
+		t.Errorf("statictest: contents of '%s' differ, %d bytes static, %d bytes local", name, len(sb), len(lb))
+	}
+}
+
+func conformHTTP(t testing.TB, staticFiles, localFiles *static.Files, name string) {
+
+	t.Helper()
+
+	serve := func(files *static.Files) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		http.FileServer(files.FS()).ServeHTTP(resp, httptest.NewRequest("GET", name, nil))
+		return resp
+	}
+
+	sresp, lresp := serve(staticFiles), serve(localFiles)
+
+	if sresp.Code != lresp.Code {
+		t.Errorf("statictest: serving '%s' returned status %d static, %d local", name, sresp.Code, lresp.Code)
+		return
+	}
+
+	if sresp.Header().Get("Content-Type") != lresp.Header().Get("Content-Type") {
+		t.Errorf("statictest: serving '%s' returned Content-Type %q static, %q local", name, sresp.Header().Get("Content-Type"), lresp.Header().Get("Content-Type"))
+	}
+
+	if sresp.Body.String() != lresp.Body.String() {
+		t.Errorf("statictest: serving '%s' returned different bodies", name)
+	}
+}
+
+// walk returns every path within files, in lexical order
+func walk(files *static.Files) ([]string, error) {
+
+	var paths []string
+
+	err := files.Walk("/", func(path string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		paths = append(paths, path)
+
+		return nil
+	})
+
+	return paths, err
+}
+
+func equal(a, b []string) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
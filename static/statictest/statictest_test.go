@@ -0,0 +1,281 @@
+package statictest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/go-playground/statics/generator"
+	"github.com/go-playground/statics/static"
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+// NOTES:
+// - Run "go test" to run tests
+// - Run "gocov test | gocov report" to report on test converage by file
+// - Run "gocov test | gocov annotate -" to report on all code and functions, those ,marked with "MISS" were never called
+//
+// or
+//
+// -- may be a good idea to change to output path to somewherelike /tmp
+// go test -coverprofile cover.out && go tool cover -html=cover.out -o cover.html
+//
+
+// recorder records the errors reported by the helpers rather than failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func testBuilder() *Builder {
+	return NewBuilder().
+		File("/index.html", "<html><body></body></html>").
+		File("assets/app.js", "alert(1)").
+		File("/assets/css/app.css", "body {}").
+		Bytes("/assets/img/logo.png", []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'})
+}
+
+func TestBuilder(t *testing.T) {
+
+	b := testBuilder()
+
+	files := b.Build(t)
+
+	AssertExists(t, files, "/assets/app.js")
+	AssertNotExists(t, files, "/assets/missing.js")
+	AssertContents(t, files, "/assets/css/app.css", "body {}")
+	AssertListing(t, files, "/assets", "img", "css", "app.js")
+	AssertListing(t, files, "/", "index.html", "assets")
+
+	local := b.BuildLocal(t)
+
+	AssertContents(t, local, "/assets/css/app.css", "body {}")
+	AssertListing(t, local, "/assets", "img", "css", "app.js")
+
+	fi, err := local.Stat("/")
+	Equal(t, err, nil)
+	Equal(t, fi.IsDir(), true)
+}
+
+func TestAssertFailures(t *testing.T) {
+
+	files := testBuilder().Build(t)
+
+	r := &recorder{TB: t}
+
+	AssertExists(r, files, "/assets/missing.js")
+	AssertNotExists(r, files, "/assets/app.js")
+	AssertContents(r, files, "/assets/app.js", "alert(2)")
+	AssertContents(r, files, "/assets/missing.js", "")
+	AssertListing(r, files, "/assets", "app.js")
+	AssertListing(r, files, "/missing")
+
+	Equal(t, r.errors, []string{
+		"statictest: expected '/assets/missing.js' to exist",
+		"statictest: expected '/assets/app.js' not to exist",
+		`statictest: contents of '/assets/app.js' are "alert(1)", expected "alert(2)"`,
+		"statictest: reading '/assets/missing.js': file does not exist",
+		`statictest: listing of '/assets' is ["app.js" "css" "img"], expected ["app.js"]`,
+		"statictest: reading directory '/missing': file does not exist",
+	})
+}
+
+func TestConformance(t *testing.T) {
+
+	b := testBuilder()
+
+	Conformance(t, b.Build(t), b.BuildLocal(t))
+
+	staticFiles, err := b.Build(t).Sub("/assets")
+	Equal(t, err, nil)
+
+	localFiles, err := b.BuildLocal(t).Sub("/assets")
+	Equal(t, err, nil)
+
+	Conformance(t, staticFiles, localFiles)
+
+	// differences are reported
+	r := &recorder{TB: t}
+
+	Conformance(r, b.Build(t), testBuilder().File("/extra.txt", "extra").BuildLocal(t))
+	Equal(t, len(r.errors), 1)
+
+	r = &recorder{TB: t}
+
+	Conformance(r, b.Build(t), testBuilder().File("/assets/app.js", "alert(2)").BuildLocal(t))
+	Equal(t, r.errors, []string{
+		"statictest: contents of '/assets/app.js' differ, 8 bytes static, 8 bytes local",
+		"statictest: serving '/assets/app.js' returned different bodies",
+	})
+
+	r = &recorder{TB: t}
+
+	Conformance(r, b.Build(t), testBuilder().File("/assets/app.js", "alert(100)").BuildLocal(t))
+	Equal(t, len(r.errors), 3)
+}
+
+func TestConformanceGenerated(t *testing.T) {
+
+	root := generate(t, "../test-files/teststart", "../")
+
+	staticFiles, err := static.New(&static.Config{UseStaticFiles: true}, root)
+	Equal(t, err, nil)
+
+	abs, err := filepath.Abs("..")
+	Equal(t, err, nil)
+
+	localFiles, err := static.New(&static.Config{UseStaticFiles: false, AbsPkgPath: abs}, &static.DirFile{})
+	Equal(t, err, nil)
+
+	// symlinked files and directories are followed in both modes
+	staticFiles, err = staticFiles.Sub("/test-files/teststart")
+	Equal(t, err, nil)
+
+	localFiles, err = localFiles.Sub("/test-files/teststart")
+	Equal(t, err, nil)
+
+	AssertContents(t, staticFiles, "/symlinkeddir/realdir/doublesymlinkeddir/triplesymlinkeddir/triplefile.txt", "data\n")
+	AssertListing(t, staticFiles, "/", "plainfile.txt", "symlinkeddir", "symlinkedfile.txt")
+
+	Conformance(t, staticFiles, localFiles)
+
+	// and from "/", the directories above the generated root included, local
+	// files being read from a module holding nothing but the static files
+	teststart, err := filepath.Abs("../test-files/teststart")
+	Equal(t, err, nil)
+
+	module := t.TempDir()
+
+	Equal(t, os.Mkdir(filepath.Join(module, "test-files"), 0755), nil)
+	Equal(t, os.Symlink(teststart, filepath.Join(module, "test-files", "teststart")), nil)
+
+	root = generate(t, filepath.Join(module, "test-files", "teststart"), module)
+
+	staticFiles, err = static.New(&static.Config{UseStaticFiles: true}, root)
+	Equal(t, err, nil)
+
+	localFiles, err = static.New(&static.Config{UseStaticFiles: false, AbsPkgPath: module}, &static.DirFile{})
+	Equal(t, err, nil)
+
+	AssertListing(t, staticFiles, "/", "test-files")
+	AssertListing(t, staticFiles, "/test-files", "teststart")
+
+	Conformance(t, staticFiles, localFiles)
+}
+
+// generate runs the generator over dir and returns the *static.DirFile its
+// generated source passes to static.New
+func generate(t *testing.T, dir string, prefix string) *static.DirFile {
+
+	t.Helper()
+
+	var buff bytes.Buffer
+
+	err := generator.Generate(context.Background(), &generator.Options{
+		StaticDir:  dir,
+		OutputFile: "assets.go",
+		Pkg:        "assets",
+		Group:      "assets",
+		Prefix:     prefix,
+	}, &buff)
+	if err != nil {
+		t.Fatalf("generating '%s': %s", dir, err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "assets.go", buff.Bytes(), 0)
+	if err != nil {
+		t.Fatalf("parsing generated source: %s", err)
+	}
+
+	var root *static.DirFile
+
+	ast.Inspect(f, func(n ast.Node) bool {
+
+		if root != nil {
+			return false
+		}
+
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+
+		if sel, ok := lit.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "DirFile" {
+			root = dirFile(t, lit)
+		}
+
+		return root == nil
+	})
+
+	if root == nil {
+		t.Fatalf("generated source contains no static.DirFile")
+	}
+
+	return root
+}
+
+// dirFile evaluates the generated static.DirFile composite literal lit
+func dirFile(t *testing.T, lit *ast.CompositeLit) *static.DirFile {
+
+	t.Helper()
+
+	df := new(static.DirFile)
+
+	for _, elt := range lit.Elts {
+
+		kv := elt.(*ast.KeyValueExpr)
+		value := kv.Value
+
+		// os.FileMode(420)
+		if call, ok := value.(*ast.CallExpr); ok {
+			value = call.Args[0]
+		}
+
+		switch v := value.(type) {
+		case *ast.BasicLit:
+
+			switch kv.Key.(*ast.Ident).Name {
+			case "Path":
+				df.Path, _ = strconv.Unquote(v.Value)
+			case "Name":
+				df.Name, _ = strconv.Unquote(v.Value)
+			case "Compressed":
+				df.Compressed, _ = strconv.Unquote(v.Value)
+			case "Size":
+				df.Size, _ = strconv.ParseInt(v.Value, 10, 64)
+			case "ModTime":
+				df.ModTime, _ = strconv.ParseInt(v.Value, 10, 64)
+			case "Mode":
+				mode, _ := strconv.ParseUint(v.Value, 10, 32)
+				df.Mode = os.FileMode(mode)
+			}
+
+		case *ast.Ident:
+			df.IsDir = v.Name == "true"
+
+		case *ast.CompositeLit:
+
+			for _, nested := range v.Elts {
+				df.Files = append(df.Files, dirFile(t, nested.(*ast.CompositeLit)))
+			}
+
+		default:
+			t.Fatalf("unexpected generated static.DirFile field %s", kv.Key)
+		}
+	}
+
+	return df
+}
@@ -0,0 +1,188 @@
+package static
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	globStar = "**"
+)
+
+// Walk walks the file tree rooted at root, calling fn for each file or directory
+// in the tree, including root, with the same semantics as fs.WalkDir.
+// Paths passed to fn are slash separated and symlinks are followed in both static
+// and local mode. root is cleaned first, so paths are always rooted i.e. /css/app.css
+func (f *Files) Walk(root string, fn fs.WalkDirFunc) error {
+
+	var d fs.DirEntry
+
+	root = cleanPath(root)
+
+	fi, err := f.Stat(root)
+	if err == nil {
+		d = fs.FileInfoToDirEntry(fi)
+		err = f.walk(root, d, fn)
+	} else {
+		err = fn(root, nil, err)
+	}
+
+	if err == fs.SkipDir || err == fs.SkipAll {
+		return nil
+	}
+
+	return err
+}
+
+func (f *Files) walk(name string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+
+	if err := fn(name, d, nil); err != nil || !d.IsDir() {
+
+		if err == fs.SkipDir && d.IsDir() {
+			err = nil
+		}
+
+		return err
+	}
+
+	fis, err := f.ReadDir(name)
+	if err != nil {
+
+		// second call, to report ReadDir error
+		if err = fn(name, d, err); err != nil {
+
+			if err == fs.SkipDir {
+				err = nil
+			}
+
+			return err
+		}
+	}
+
+	for _, fi := range fis {
+
+		if err = f.walk(path.Join(name, fi.Name()), fs.FileInfoToDirEntry(fi), fn); err != nil {
+
+			if err == fs.SkipDir {
+				break
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Glob returns the slash separated paths of all files and directories matching
+// pattern, in lexical order. The pattern syntax is that of path.Match with the
+// addition of "**" as a whole path element, which matches zero or more
+// directories i.e. /templates/**/*.tmpl
+func (f *Files) Glob(pattern string) ([]string, error) {
+
+	if !strings.HasPrefix(pattern, pathSep) {
+		pattern = pathSep + pattern
+	}
+
+	if err := validGlob(pattern); err != nil {
+		return nil, err
+	}
+
+	// start walking from the deepest directory without any pattern characters
+	elems := strings.Split(pattern, pathSep)
+	root := pathSep
+
+	for i := 1; i < len(elems)-1 && !hasMeta(elems[i]); i++ {
+		root = path.Join(root, elems[i])
+	}
+
+	depth := strings.Count(pattern, pathSep)
+	unbounded := strings.Contains(pattern, globStar)
+
+	var matches []string
+
+	err := f.Walk(root, func(p string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+
+			if p == root && os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+
+			return err
+		}
+
+		if matchGlob(pattern, p) {
+			matches = append(matches, p)
+		}
+
+		if d.IsDir() && !unbounded && p != pathSep && strings.Count(p, pathSep) >= depth {
+			return fs.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(matches)
+
+	return matches, nil
+}
+
+func hasMeta(elem string) bool {
+	return strings.ContainsAny(elem, `*?[\`)
+}
+
+func validGlob(pattern string) error {
+
+	for _, elem := range strings.Split(pattern, pathSep) {
+
+		if _, err := path.Match(elem, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchGlob reports whether name matches pattern, both of which must be valid
+// and slash separated.
+func matchGlob(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, pathSep), strings.Split(name, pathSep))
+}
+
+func matchElems(pattern, name []string) bool {
+
+	for len(pattern) > 0 {
+
+		if pattern[0] == globStar {
+
+			for i := 0; i <= len(name); i++ {
+
+				if matchElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}